@@ -0,0 +1,42 @@
+package flogger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+/*	Количество фреймов от runtime.Callers до пользовательского кода:
+**	runtime.Callers -> captureCaller (captureStack) -> LoggerType.log -> публичный метод логгера -> пользовательский код  */
+const callerBaseSkip = 4
+
+/*	Запоминает только адрес вызова - это дешево. Имя файла, строка и функция вычисляются уже в горутине записи  */
+func captureCaller(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(callerBaseSkip+skip, pcs[:]) < 1 {
+		return 0
+	}
+	return pcs[0]
+}
+
+/*	Возвращает caller в виде "pkg/file.go:123" и короткое имя функции "pkg.(*Type).Method"  */
+func resolveCaller(pc uintptr) (caller string, function string) {
+	if pc == 0 {
+		return "", ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return trimPath(frame.File, 2) + ":" + strconv.Itoa(frame.Line), trimPath(frame.Function, 1)
+}
+
+/*	Оставляет от пути последние count элементов  */
+func trimPath(path string, count int) string {
+	end := len(path)
+	for i := 0; i < count; i++ {
+		index := strings.LastIndexByte(path[:end], '/')
+		if index < 0 {
+			return path
+		}
+		end = index
+	}
+	return path[end+1:]
+}
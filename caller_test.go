@@ -0,0 +1,106 @@
+package flogger
+
+import (
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+/*	Пользовательская обертка над логгером - для нее нужен CallerSkip = 1  */
+func wrappedWarning(logger *LoggerType, msg string) {
+	logger.Warning(nil, nil, msg)
+}
+
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestCaller(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	file = trimPath(file, 2)
+
+	t.Run("direct call", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.CallerLevels = []string{"WARNING"}
+		loggerConf.CallerWithFunc = true
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		line := currentLine() + 1
+		logger.Warning(nil, nil, "with caller")
+		logger.Info(nil, "without caller")
+
+		lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+		if len(lines) != 2 {
+			t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+			t.FailNow()
+		}
+		var withCaller, withoutCaller map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &withCaller); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if err := json.Unmarshal([]byte(lines[1]), &withoutCaller); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if expected := file + ":" + strconv.Itoa(line); withCaller["caller"] != expected {
+			t.Errorf("%sFail: expected caller %s got %v%s", RED_BG, expected, withCaller["caller"], NO_COLOR)
+		}
+		if function, _ := withCaller["func"].(string); strings.HasPrefix(function, "file_logger.TestCaller") == false {
+			t.Errorf("%sFail: unexpected func %v%s", RED_BG, withCaller["func"], NO_COLOR)
+		}
+		if _, isExists := withoutCaller["caller"]; isExists == true {
+			t.Errorf("%sFail: caller is not expected for INFO%s", RED_BG, NO_COLOR)
+		}
+	})
+
+	t.Run("wrapper with skip", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.CallerLevels = []string{"warning"}
+		loggerConf.CallerSkip = 1
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		line := currentLine() + 1
+		wrappedWarning(logger, "through wrapper")
+
+		body := stopAndReadLogFile(t, logger, wg, "default")
+		if expected := `"caller":"` + file + ":" + strconv.Itoa(line) + `",`; strings.Contains(body, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, body, NO_COLOR)
+		}
+		if strings.Contains(body, `"func"`) == true {
+			t.Errorf("%sFail: func is not expected in %s%s", RED_BG, body, NO_COLOR)
+		}
+	})
+
+	t.Run("unknown level", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.CallerLevels = []string{"TRACE"}
+
+		if _, err := NewLogger(&sync.WaitGroup{}); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+}
+
+/*	go test -bench . -benchmem  */
+func BenchmarkCaptureCaller(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = captureCaller(0)
+	}
+}
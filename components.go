@@ -0,0 +1,143 @@
+package flogger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*	Ключ поля с именем компонента (Named)  */
+const componentFieldKey = "component"
+
+/*	Настройки уровней по компонентам. Общие для логгера и всех его дочерних логгеров, заменяются целиком
+**	(SetComponentLevels) - поэтому изменения во время работы видны всем логгерам сразу  */
+type componentLevelsType struct {
+	rules atomic.Pointer[componentRulesType]
+}
+
+/*	Состояние уровня для компонента: 0 - не задано (действует параметр Enable*), 1 - включен, -1 - выключен  */
+type componentLevelStatesType [levelCount]int8
+
+type componentPrefixType struct {
+	prefix string
+	states componentLevelStatesType
+}
+
+/*	Неизменяемый набор правил. Итоговые состояния уровней для имени компонента вычисляются один раз и кешируются  */
+type componentRulesType struct {
+	exact    map[string]componentLevelStatesType
+	prefixes []componentPrefixType // от самого длинного префикса к самому короткому
+	cache    sync.Map              // имя компонента -> componentLevelStatesType
+}
+
+/*	Ключ мапы - имя компонента (payments), префикс с * на конце (payments.*, pay*) или * для всех компонентов.
+**	Значение - уровни через запятую или пробел: +DEBUG (или DEBUG) включает уровень, -QUERY выключает.
+**	FATAL и ERROR выключить нельзя  */
+func newComponentRules(levels map[string]string) (*componentRulesType, error) {
+	rules := &componentRulesType{
+		exact: make(map[string]componentLevelStatesType),
+	}
+	for pattern, levelList := range levels {
+		states, err := parseComponentLevels(pattern, levelList)
+		if err != nil {
+			return nil, err
+		}
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.Contains(prefix, "*") == true {
+			return nil, fmt.Errorf("Параметр ComponentLevels конфигурации модуля flogger: * может быть только в конце имени компонента (задан %s)", pattern)
+		}
+		if prefix != pattern {
+			rules.prefixes = append(rules.prefixes, componentPrefixType{prefix: prefix, states: states})
+		} else {
+			rules.exact[pattern] = states
+		}
+	}
+	sort.Slice(rules.prefixes, func(i, j int) bool {
+		return len(rules.prefixes[i].prefix) > len(rules.prefixes[j].prefix)
+	})
+	return rules, nil
+}
+
+func parseComponentLevels(pattern string, levelList string) (componentLevelStatesType, error) {
+	var states componentLevelStatesType
+	for _, token := range strings.FieldsFunc(levelList, func(r rune) bool { return r == ',' || r == ' ' }) {
+		var state int8 = 1
+		if strings.HasPrefix(token, "-") == true {
+			state = -1
+		}
+		levelName := strings.TrimLeft(token, "+-")
+		var isFound bool
+		for level := LevelType(0); level < levelCount; level++ {
+			if strings.EqualFold(levelName, gLevelNames[level]) == false {
+				continue
+			}
+			if (level == levelFatal || level == levelError) && state < 0 {
+				return states, fmt.Errorf("Параметр ComponentLevels конфигурации модуля flogger: уровень %s нельзя выключить (компонент %s)", levelName, pattern)
+			}
+			states[level] = state
+			isFound = true
+		}
+		if isFound == false {
+			return states, fmt.Errorf("Параметр ComponentLevels конфигурации модуля flogger содержит неизвестный уровень %s (компонент %s)", levelName, pattern)
+		}
+	}
+	return states, nil
+}
+
+/*	Для каждого уровня побеждает самое точное правило: имя компонента, затем самый длинный подходящий префикс  */
+func (this *componentRulesType) resolve(component string) componentLevelStatesType {
+	if cached, isExists := this.cache.Load(component); isExists == true {
+		return cached.(componentLevelStatesType)
+	}
+	states := this.exact[component]
+	for _, prefix := range this.prefixes {
+		if strings.HasPrefix(component, prefix.prefix) == false {
+			continue
+		}
+		for level := range states {
+			if states[level] == 0 {
+				states[level] = prefix.states[level]
+			}
+		}
+	}
+	this.cache.Store(component, states)
+	return states
+}
+
+/*	0 - для компонента уровень не задан  */
+func (this *componentLevelsType) levelState(component string, level LevelType) int8 {
+	if this == nil {
+		return 0
+	}
+	rules := this.rules.Load()
+	if rules == nil {
+		return 0
+	}
+	return rules.resolve(component)[level]
+}
+
+/*	Дочерний логгер компонента - в каждую запись добавляется поле component, а включенные уровни определяются
+**	параметром ComponentLevels (для уровней которые там не заданы действуют параметры Enable*).
+**	Имена вложенных компонентов пишутся через точку (payments.stripe)  */
+func (this *LoggerType) Named(name string) *LoggerType {
+	component := name
+	if this.component != "" {
+		component = this.component + "." + name
+	}
+	child := this.With(map[string]interface{}{componentFieldKey: component})
+	child.component = component
+	return child
+}
+
+/*	Заменяет настройки уровней по компонентам (формат как у параметра ComponentLevels) для логгера и всех
+**	его дочерних логгеров. При ошибке действующие настройки не меняются  */
+func (this *LoggerType) SetComponentLevels(levels map[string]string) error {
+	rules, err := newComponentRules(levels)
+	if err != nil {
+		return err
+	}
+	this.componentLevels.rules.Store(rules)
+	return nil
+}
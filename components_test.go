@@ -0,0 +1,101 @@
+package flogger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestComponentRules(t *testing.T) {
+	rules, err := newComponentRules(map[string]string{
+		"*":              "-QUERY",
+		"payments*":      "+DEBUG",
+		"payments.cache": "-DEBUG, +QUERY",
+		"cache.*":        "-INFO -DECISION",
+	})
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	var testCases = []struct {
+		component string
+		level     LevelType
+		expected  int8
+	}{
+		{component: "payments", level: levelServiceDebug, expected: 1},
+		{component: "payments", level: levelBusinessDebug, expected: 1},
+		{component: "payments", level: levelQuery, expected: -1},
+		{component: "payments.cache", level: levelServiceDebug, expected: -1},
+		{component: "payments.cache", level: levelQuery, expected: 1},
+		{component: "cache", level: levelInfo, expected: 0},
+		{component: "cache.redis", level: levelInfo, expected: -1},
+		{component: "cache.redis", level: levelWarning, expected: 0},
+		{component: "orders", level: levelQuery, expected: -1},
+		{component: "orders", level: levelDecision, expected: 0},
+	}
+	for _, tc := range testCases {
+		/*	Второй проход - из кеша  */
+		for i := 0; i < 2; i++ {
+			if state := rules.resolve(tc.component)[tc.level]; state != tc.expected {
+				t.Errorf("%sFail: component %s level %s expected %d got %d%s", RED_BG, tc.component, tc.level, tc.expected, state, NO_COLOR)
+			}
+		}
+	}
+
+	for _, levels := range []map[string]string{
+		{"payments": "-ERROR"},
+		{"payments": "+TRACE"},
+		{"pay*ments": "+DEBUG"},
+	} {
+		if _, err := newComponentRules(levels); err == nil {
+			t.Errorf("%sFail: expected error for %v%s", RED_BG, levels, NO_COLOR)
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.ComponentLevels = map[string]string{"payments*": "+DEBUG"}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	payments := logger.Named("payments").Named("stripe")
+	cache := logger.Named("cache")
+
+	payments.ServiceDebug(nil, "charge created")
+	cache.ServiceDebug(nil, "disabled level")
+	logger.ServiceDebug(nil, "disabled level")
+
+	if err := logger.SetComponentLevels(map[string]string{"payments": "-ERROR"}); err == nil {
+		t.Errorf("%sFail: expected error for invalid levels%s", RED_BG, NO_COLOR)
+	}
+	payments.ServiceDebug(nil, "invalid levels are ignored")
+
+	if err := logger.SetComponentLevels(map[string]string{"cache": "+DEBUG", "payments.*": "-INFO"}); err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+	}
+	payments.ServiceDebug(nil, "disabled at runtime")
+	payments.Info(nil, "disabled at runtime")
+	cache.ServiceDebug(nil, "enabled at runtime")
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 3 {
+		t.Errorf("%sFail: expected 3 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	expected := []string{
+		`"level":"DEBUG","component":"payments.stripe","message":"charge created"}`,
+		`"level":"DEBUG","component":"payments.stripe","message":"invalid levels are ignored"}`,
+		`"level":"DEBUG","component":"cache","message":"enabled at runtime"}`,
+	}
+	for i := range expected {
+		if strings.Contains(lines[i], expected[i]) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected[i], lines[i], NO_COLOR)
+		}
+	}
+}
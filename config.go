@@ -5,20 +5,50 @@ import (
 )
 
 type ConfigType struct {
-	ServiceName              string `conf:"ServiceName"`
-	LogFolder                string `conf:"LogFolder" env:"true"`
-	Permissions              string `conf:"Permissions"`
-	MaxHoursToChangeLogFile  uint   `conf:"MaxHoursToChangeLogFile" min:"1" max:"24"`
-	MaxBufSize               uint   `conf:"MaxBufSize" min:"1"`
-	FileWriteDurationSeconds uint   `conf:"FileWriteDurationSeconds" min:"1"`
-	WriteChanSize            uint   `conf:"WriteChanSize" min:"1"`
-	EnableServiceDebug       bool   `conf:"EnableServiceDebug"`
-	EnableBusinessDebug      bool   `conf:"EnableBusinessDebug"`
-	EnableQuery              bool   `conf:"EnableQuery"`
-	EnableImportant          bool   `conf:"EnableImportant"`
-	EnableDecision           bool   `conf:"EnableDecision"`
-	EnableFileForQuery       bool   `conf:"EnableFileForQuery"`
-	EnableFileForImportant   bool   `conf:"EnableFileForImportant"`
+	ServiceName              string            `conf:"ServiceName"`
+	LogFolder                string            `conf:"LogFolder" env:"true"`
+	Permissions              string            `conf:"Permissions"`
+	MaxHoursToChangeLogFile  uint              `conf:"MaxHoursToChangeLogFile" min:"1" max:"24"`
+	RotationInterval         string            `conf:"RotationInterval"` // 10m, 1h, 24h, weekly, monthly. Если пустой - используется MaxHoursToChangeLogFile
+	MaxBufSize               uint              `conf:"MaxBufSize" min:"1"`
+	FileWriteDurationSeconds uint              `conf:"FileWriteDurationSeconds" min:"1"`
+	WriteChanSize            uint              `conf:"WriteChanSize" min:"1"`
+	EnableServiceDebug       bool              `conf:"EnableServiceDebug"`
+	EnableBusinessDebug      bool              `conf:"EnableBusinessDebug"`
+	EnableQuery              bool              `conf:"EnableQuery"`
+	EnableImportant          bool              `conf:"EnableImportant"`
+	EnableDecision           bool              `conf:"EnableDecision"`
+	EnableFileForQuery       bool              `conf:"EnableFileForQuery"`
+	EnableFileForImportant   bool              `conf:"EnableFileForImportant"`
+	DefaultFileEncoder       string            `conf:"DefaultFileEncoder"`   // Формат вывода в файл (см. RegisterEncoder). Если пустой - json
+	ImportantFileEncoder     string            `conf:"ImportantFileEncoder"` // Формат вывода в файл (см. RegisterEncoder). Если пустой - json
+	QueryFileEncoder         string            `conf:"QueryFileEncoder"`     // Формат вывода в файл (см. RegisterEncoder). Если пустой - json
+	ConsoleOutput            string            `conf:"ConsoleOutput"`        // stdout / stderr - дублирование всех записей в консоль. Если пустой - вывод в консоль выключен
+	ConsoleEncoder           string            `conf:"ConsoleEncoder"`       // Формат вывода в консоль. Если пустой - console
+	ConsoleLevels            []string          `conf:"ConsoleLevels"`        // Уровни которые выводятся в консоль. Если пустой - те же что и в файлы
+	TimestampUnit            string            `conf:"TimestampUnit"`        // Единицы поля stamp: s / ms / us / ns. Если пустой - секунды
+	TimeLayout               string            `conf:"TimeLayout"`           // rfc3339nano / datetime / свой layout в формате Go. Если пустой - 15:04:05
+	TimeWithDate             bool              `conf:"TimeWithDate"`         // Добавить дату в поле time (для rfc3339nano и datetime дата есть всегда)
+	KeepFieldsOrder          bool              `conf:"KeepFieldsOrder"`      // Не сортировать поля переданные упорядоченным списком ([]FieldType)
+	FloatFormat              string            `conf:"FloatFormat"`          // g (кратчайшая запись) / f (фиксированная точность) / e (экспонента). Если пустой - g
+	FloatPrecision           int               `conf:"FloatPrecision"`       // Количество знаков для форматов f и e. Ноль или отрицательное - минимально необходимое
+	FloatNonFinite           string            `conf:"FloatNonFinite"`       // NaN и ±Inf: string (строкой) / null. Если пустой - string
+	CallerLevels             []string          `conf:"CallerLevels"`         // Уровни для которых в запись добавляется место вызова (caller)
+	CallerSkip               uint              `conf:"CallerSkip"`           // Сколько фреймов пропустить дополнительно (для пользовательских оберток над логгером)
+	CallerWithFunc           bool              `conf:"CallerWithFunc"`       // Добавлять к caller имя функции (поле func)
+	StackLevels              []string          `conf:"StackLevels"`          // Уровни для которых в запись добавляется стек (поле stack)
+	StackDepth               uint              `conf:"StackDepth"`           // Максимальная глубина стека. Если 0 - 32
+	StaticFields             map[string]string `conf:"StaticFields"`         // Поля которые добавляются в каждую запись (env, region...)
+	ErrorCausesDepth         uint              `conf:"ErrorCausesDepth"`     // Глубина разворачивания обернутых ошибок в поле causes. Если 0 - выключено
+	FieldNaming              string            `conf:"FieldNaming"`          // Имена полей json энкодера: native / ecs / otel. Если пустой - native
+	SchemaVersionField       bool              `conf:"SchemaVersionField"`   // Добавлять в каждую запись поле v с версией формата (SchemaVersion)
+	MaxMessageLength         uint              `conf:"MaxMessageLength"`     // Максимальная длина сообщения в байтах. Если 0 - без ограничения
+	MaxStringFieldLength     uint              `conf:"MaxStringFieldLength"` // Максимальная длина строкового значения поля (на любой вложенности) и сообщения ошибки. Если 0 - без ограничения
+	MaxFieldsCount           uint              `conf:"MaxFieldsCount"`       // Максимальное количество полей записи (из всех источников вместе). Если 0 - без ограничения
+	MaxNestingDepth          uint              `conf:"MaxNestingDepth"`      // Максимальная вложенность мап и слайсов в значении поля. Если 0 - без ограничения
+	MaxRecordSize            uint              `conf:"MaxRecordSize"`        // Максимальный размер сериализованной записи в байтах. Если 0 - без ограничения
+	StaticProviders          []string          `conf:"StaticProviders"`      // Вычисляемые при старте поля: host, pid, service, version
+	ComponentLevels          map[string]string `conf:"ComponentLevels"`      // Уровни по компонентам (Named): имя или префикс с * -> +DEBUG, -QUERY...
 }
 
 /*	Глобальная структура конфига  */
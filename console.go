@@ -0,0 +1,63 @@
+package flogger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	consoleStdout = "stdout"
+	consoleStderr = "stderr"
+)
+
+/*	Вывод в консоль устроен так же как файл (буффер + горутина записи), только без смены файла и без закрытия  */
+func newConsoleFile(conf *ConfigType, format *formatType, limits *limitsType) (*fileType, error) {
+	var osFile *os.File
+	switch conf.ConsoleOutput {
+	case consoleStdout:
+		osFile = os.Stdout
+	case consoleStderr:
+		osFile = os.Stderr
+	default:
+		return nil, fmt.Errorf("Параметр ConsoleOutput конфигурации модуля flogger может быть только %s или %s", consoleStdout, consoleStderr)
+	}
+	encoderName := conf.ConsoleEncoder
+	if encoderName == "" {
+		encoderName = consoleEncoderName
+	}
+	encoder, err := getEncoder(encoderName)
+	if err != nil {
+		return nil, err
+	}
+	/*	Цвета имеют смысл только в терминале - при перенаправлении в файл или пайп они выключаются  */
+	if consoleEncoder, isConsoleEncoder := encoder.(consoleEncoderType); isConsoleEncoder == true {
+		consoleEncoder.isColored = isTerminal(osFile)
+		encoder = consoleEncoder
+	}
+	return &fileType{
+		maxBufSize:    conf.MaxBufSize,
+		writeChanSize: conf.WriteChanSize,
+		fileTypeName:  "console",
+		isConsole:     true,
+		osFile:        osFile,
+		encoder:       encoder,
+		format:        format,
+		limits:        limits,
+		bmu:           &sync.Mutex{},
+		buf:           make([]messageType, 0, int(conf.MaxBufSize)+1),
+		writeChan:     make(chan []messageType, int(conf.WriteChanSize)),
+	}, nil
+}
+
+/*	Переменная окружения NO_COLOR (https://no-color.org) выключает цвета даже в терминале  */
+func isTerminal(osFile *os.File) bool {
+	if _, isExists := os.LookupEnv("NO_COLOR"); isExists == true {
+		return false
+	}
+	info, err := osFile.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
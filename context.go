@@ -0,0 +1,41 @@
+package flogger
+
+import (
+	"context"
+	"sync"
+)
+
+var gContextExtractorsMu = &sync.RWMutex{}
+
+var gContextExtractors []func(ctx context.Context) map[string]interface{}
+
+/*	Регистрирует функцию извлечения полей из контекста (request id, trace id, tenant...). Извлечение выполняется
+**	в методах InfoCtx, ErrorCtx... и только для включенных уровней. Поля экстракторов зарегистрированных позже
+**	побеждают при совпадении ключей, а поля переданные в вызове побеждают поля контекста  */
+func RegisterContextExtractor(extractor func(ctx context.Context) map[string]interface{}) {
+	gContextExtractorsMu.Lock()
+	gContextExtractors = append(gContextExtractors, extractor)
+	gContextExtractorsMu.Unlock()
+}
+
+func extractContextFields(ctx context.Context) []FieldType {
+	if ctx == nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	gContextExtractorsMu.RLock()
+	for _, extractor := range gContextExtractors {
+		extracted := extractor(ctx)
+		if len(extracted) == 0 {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]interface{}, len(extracted))
+		}
+		for key, value := range extracted {
+			fields[key] = value
+		}
+	}
+	gContextExtractorsMu.RUnlock()
+	return sortFields(fields)
+}
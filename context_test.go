@@ -0,0 +1,53 @@
+package flogger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type testContextKeyType struct{}
+
+func TestContextExtractor(t *testing.T) {
+	var calls atomic.Int64
+	RegisterContextExtractor(func(ctx context.Context) map[string]interface{} {
+		calls.Add(1)
+		requestId, _ := ctx.Value(testContextKeyType{}).(string)
+		if requestId == "" {
+			return nil
+		}
+		return map[string]interface{}{"request_id": requestId, "tenant": "t1", "worker_num": 0}
+	})
+
+	newTestConfig(t)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), testContextKeyType{}, "r1")
+	child := logger.With(map[string]interface{}{"tenant": "bound", "user_id": 7})
+
+	child.InfoCtx(ctx, map[string]interface{}{"worker_num": 2}, "request %d", 1)
+	logger.WarningCtx(context.Background(), nil, nil, "empty context")
+	logger.ServiceDebugCtx(ctx, nil, "disabled level")
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 2 {
+		t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	if expected := `"level":"INFO","request_id":"r1","tenant":"t1","user_id":7,"worker_num":2,"message":"request 1"}`; strings.Contains(lines[0], expected) == false {
+		t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[0], NO_COLOR)
+	}
+	if expected := `"level":"WARNING","message":"empty context"}`; strings.Contains(lines[1], expected) == false {
+		t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[1], NO_COLOR)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("%sFail: extractor must run only for enabled levels, calls %d%s", RED_BG, calls.Load(), NO_COLOR)
+	}
+}
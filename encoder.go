@@ -0,0 +1,116 @@
+package flogger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultEncoderName = "json"
+
+/*	Запись лога в том виде в котором она передается энкодеру  */
+type RecordType struct {
+	Time     time.Time
+	Level    string
+	Static   *StaticFieldsType // статические поля логгера, nil если их нет
+	Error    *ErrorType        // nil если запись без ошибки
+	Caller   string            // место вызова "pkg/file.go:123", пустое если выключено для уровня
+	Function string            // функция в которой был вызов, пустая если выключено
+	Stack    []StackFrameType  // стек ошибки либо места вызова, nil если выключено для уровня
+	Fields   []FieldType       // пользовательские поля, отсортированы по ключу (значение - метод Interface)
+	Message  string
+	format   *formatType // формат логгера для встроенных энкодеров, nil - формат по умолчанию
+}
+
+func (this *RecordType) getFormat() *formatType {
+	if this.format == nil {
+		return gDefaultFormat
+	}
+	return this.format
+}
+
+/*	Поле записи. Конструкторы String, Int, Bool, Duration, Time хранят значение в integer / text без упаковки
+**	в interface{} (без аллокации), Value используется для значений любого типа (Any, мапа полей). Значение
+**	поля любого вида возвращает метод Interface - его используют пользовательские энкодеры  */
+type FieldType struct {
+	Key     string
+	Value   interface{}   // значение поля вида fieldKindAny, ошибка Err, *time.Location поля Time
+	kind    fieldKindType // нулевое значение - fieldKindAny, поэтому FieldType{Key, Value} остается валидным
+	integer int64         // Int, Int64, Bool (0 / 1), Duration, Time (UnixNano)
+	text    string        // String
+}
+
+type fieldKindType uint8
+
+const (
+	fieldKindAny fieldKindType = iota
+	fieldKindString
+	fieldKindInt64
+	fieldKindBool
+	fieldKindDuration
+	fieldKindTime
+	fieldKindError // текст ошибки получается через errorHandler логгера при сериализации (toRecord)
+)
+
+/*	Значение поля в interface{}. Для типизированных полей аллоцирует - встроенные энкодеры его не используют  */
+func (this FieldType) Interface() interface{} {
+	switch this.kind {
+	case fieldKindString:
+		return this.text
+	case fieldKindInt64:
+		return this.integer
+	case fieldKindBool:
+		return this.integer != 0
+	case fieldKindDuration:
+		return time.Duration(this.integer)
+	case fieldKindTime:
+		return this.timeValue()
+	default:
+		return this.Value
+	}
+}
+
+func (this FieldType) timeValue() time.Time {
+	location, _ := this.Value.(*time.Location)
+	if location == nil {
+		location = time.UTC
+	}
+	return time.Unix(0, this.integer).In(location)
+}
+
+/*	Энкодер отвечает за формат вывода. Дописывает запись в буффер (вместе с разделителем записей) и возвращает
+**	его. Энкодер вызывается только из горутины записи в файл, поэтому может не заботиться о потокобезопасности  */
+type IEncoder interface {
+	Encode(dst []byte, record *RecordType) []byte
+}
+
+var gEncodersMu = &sync.RWMutex{}
+
+var gEncoders = map[string]IEncoder{
+	defaultEncoderName: jsonEncoderType{},
+	logfmtEncoderName:  logfmtEncoderType{},
+	consoleEncoderName: consoleEncoderType{},
+	msgpackEncoderName: msgpackEncoderType{},
+}
+
+/*	Регистрирует энкодер под именем которое затем можно указать в конфиге.
+**	Регистрировать нужно до вызова NewLogger  */
+func RegisterEncoder(name string, encoder IEncoder) {
+	gEncodersMu.Lock()
+	gEncoders[name] = encoder
+	gEncodersMu.Unlock()
+}
+
+/*	Пустое имя - энкодер по умолчанию (компактный json)  */
+func getEncoder(name string) (IEncoder, error) {
+	if name == "" {
+		name = defaultEncoderName
+	}
+	gEncodersMu.RLock()
+	encoder, isExists := gEncoders[name]
+	gEncodersMu.RUnlock()
+	if isExists == false {
+		return nil, fmt.Errorf("Энкодер %s не зарегистрирован в модуле flogger", name)
+	}
+	return encoder, nil
+}
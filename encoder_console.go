@@ -0,0 +1,110 @@
+package flogger
+
+import (
+	"strconv"
+)
+
+const consoleEncoderName = "console"
+
+const (
+	colorReset    = "\033[m"
+	colorRed      = "\033[31m"
+	colorGreen    = "\033[32m"
+	colorYellow   = "\033[33m"
+	colorBlue     = "\033[34m"
+	colorMagenta  = "\033[35m"
+	colorCyan     = "\033[36m"
+	colorRedBg    = "\033[41;30m"
+	colorYellowBg = "\033[43;30m"
+	colorGray     = "\033[90m"
+)
+
+/*	Ширина самого длинного уровня (IMPORTANT) - по ней выравниваются тэги  */
+const consoleLevelWidth = 9
+
+var consoleLevelColors = map[string]string{
+	fatalLevel:        colorRedBg,
+	errorLevel:        colorRed,
+	warningLevel:      colorYellow,
+	infoLevel:         colorGreen,
+	serviceDebugLevel: colorBlue,
+	queryLevel:        colorCyan,
+	importantLevel:    colorYellowBg,
+	decisionLevel:     colorMagenta,
+}
+
+/*	Человекочитаемый формат для локальной разработки: выровненный цветной уровень, время, сообщение, затем
+**	ошибка и поля в виде key=value. Цвета включаются только если вывод идет в терминал (см. newConsoleFile)  */
+type consoleEncoderType struct {
+	isColored bool
+}
+
+func (this consoleEncoderType) Encode(dst []byte, record *RecordType) []byte {
+	format := record.getFormat()
+	color := consoleLevelColors[record.Level]
+	if this.isColored == true && color != "" {
+		dst = append(dst, color...)
+	}
+	dst = append(dst, record.Level...)
+	if this.isColored == true && color != "" {
+		dst = append(dst, colorReset...)
+	}
+	for i := len(record.Level); i < consoleLevelWidth; i++ {
+		dst = append(dst, ' ')
+	}
+
+	dst = append(dst, ' ')
+	if this.isColored == true {
+		dst = append(dst, colorGray...)
+	}
+	dst = append(dst, format.timeText(record.Time)...)
+	if this.isColored == true {
+		dst = append(dst, colorReset...)
+	}
+
+	dst = append(dst, ' ')
+	dst = appendConsoleMessage(dst, record.Message)
+	if record.Static != nil {
+		dst = append(dst, record.Static.logfmt...)
+	}
+
+	if record.Error != nil {
+		if record.Error.Code != 0 && record.Error.Type != "" {
+			dst = append(dst, " error.code="...)
+			dst = strconv.AppendUint(dst, uint64(record.Error.Code), 10)
+			dst = append(dst, " error.type="...)
+			dst = appendLogfmtString(dst, record.Error.Type)
+		}
+		dst = append(dst, " error="...)
+		dst = appendLogfmtString(dst, record.Error.Message)
+		dst = appendLogfmtCauses(dst, record.Error.Causes)
+	}
+	if record.Caller != "" {
+		dst = append(dst, " caller="...)
+		dst = appendLogfmtString(dst, record.Caller)
+	}
+	if record.Function != "" {
+		dst = append(dst, " func="...)
+		dst = appendLogfmtString(dst, record.Function)
+	}
+	if len(record.Stack) > 0 {
+		dst = append(dst, " stack="...)
+		dst = appendLogfmtString(dst, stackToString(record.Stack))
+	}
+	for i := range record.Fields {
+		dst = appendLogfmtTypedField(dst, &record.Fields[i], format)
+	}
+	return append(dst, '\n')
+}
+
+/*	Сообщение пишется без кавычек, но переводы строк и управляющие символы экранируются -
+**	одна запись всегда занимает одну строку терминала  */
+func appendConsoleMessage(dst []byte, message string) []byte {
+	for i := 0; i < len(message); i++ {
+		if message[i] < ' ' || message[i] == 0x7f {
+			quoted := appendJSONString(nil, message)
+			return append(dst, quoted[1:len(quoted)-1]...)
+		}
+	}
+	return append(dst, message...)
+}
@@ -0,0 +1,189 @@
+package flogger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*	Энкодер по умолчанию  */
+type jsonEncoderType struct{}
+
+/*	Сериализуем сообщение компактнее чем позволяет стандартный маршаллер
+**	(убираем лишние пробелы между полями (как и в jsonb), параметр Args без рефлексии
+**	превращается в поля текущей структуры и сортируется по алфавиту)
+**	Соблюдается совместимость с форматом json (но из-за особенностей сериализации параметра Args
+**	данная dto недействительна при Unmarshal (нужно будет анмаршаллить в мапу))
+**	Все строки (сообщение, ключи и строковые значения полей) экранируются по RFC 8259 - пользовательский
+**	ввод не может сломать json или подделать строку лога.
+**	Имена полей и их вид задаются профилем имен (параметр FieldNaming)  */
+func (this jsonEncoderType) Encode(dst []byte, record *RecordType) []byte {
+	format := record.getFormat()
+	naming := format.naming
+	dst = append(dst, '{')
+	if format.withVersion == true {
+		dst = append(dst, "\"v\":"...)
+		dst = strconv.AppendInt(dst, SchemaVersion, 10)
+		dst = append(dst, ',')
+	}
+	if naming.stampKey != "" {
+		dst = appendJSONKey(dst, naming.stampKey)
+		dst = format.appendStamp(dst, record.Time)
+		dst = append(dst, ',')
+	}
+	dst = appendJSONKey(dst, naming.timeKey)
+	switch naming.timeFormat {
+	case timeFormatNano:
+		dst = strconv.AppendInt(dst, record.Time.UnixNano(), 10)
+	case timeFormatRFC3339:
+		dst = appendJSONString(dst, record.Time.Format(time.RFC3339Nano))
+	default:
+		/*	TimeLayout задается пользователем и может содержать кавычки и управляющие символы  */
+		dst = appendJSONString(dst, format.timeText(record.Time))
+	}
+	dst = append(dst, ',')
+	dst = appendJSONKey(dst, naming.levelKey)
+	dst = appendJSONString(dst, record.Level)
+	dst = append(dst, ',')
+	if severity, isExists := naming.severities[record.Level]; isExists == true && naming.severityKey != "" {
+		dst = appendJSONKey(dst, naming.severityKey)
+		dst = strconv.AppendInt(dst, int64(severity), 10)
+		dst = append(dst, ',')
+	}
+	if record.Static != nil {
+		dst = append(dst, record.Static.json...)
+	}
+	if record.Error != nil {
+		dst = naming.appendError(dst, record.Error)
+	}
+	if record.Caller != "" {
+		dst = naming.appendCaller(dst, record.Caller)
+	}
+	if record.Function != "" {
+		dst = appendJSONKey(dst, naming.functionKey)
+		dst = appendJSONString(dst, record.Function)
+		dst = append(dst, ',')
+	}
+	if len(record.Stack) > 0 {
+		dst = appendJSONKey(dst, naming.stackKey)
+		if naming.stackAsFrames == true {
+			dst = appendJSONStack(dst, record.Stack)
+		} else {
+			dst = appendJSONString(dst, stackToString(record.Stack))
+		}
+		dst = append(dst, ',')
+	}
+
+	path := &valuePathType{format: format}
+	for i := range record.Fields {
+		dst = appendJSONKey(dst, record.Fields[i].Key)
+		dst = appendFieldValue(dst, &record.Fields[i], path)
+		dst = append(dst, ',')
+	}
+	dst = appendJSONKey(dst, naming.messageKey)
+	dst = appendJSONString(dst, record.Message)
+	return append(dst, "}\n"...)
+}
+
+/*	"key":  */
+func appendJSONKey(dst []byte, key string) []byte {
+	dst = appendJSONString(dst, key)
+	return append(dst, ':')
+}
+
+/*	Ошибка вложенным объектом либо плоскими ключами через точку (профили ecs и otel)  */
+func (this *namingType) appendError(dst []byte, err *ErrorType) []byte {
+	if this.errorKey != "" {
+		dst = appendJSONKey(dst, this.errorKey)
+		dst = appendJSONError(dst, err)
+		return append(dst, ',')
+	}
+	if err.Code != 0 && err.Type != "" {
+		dst = appendJSONKey(dst, this.errorCodeKey)
+		dst = strconv.AppendUint(dst, uint64(err.Code), 10)
+		dst = append(dst, ',')
+		dst = appendJSONKey(dst, this.errorTypeKey)
+		dst = appendJSONString(dst, err.Type)
+		dst = append(dst, ',')
+	}
+	dst = appendJSONKey(dst, this.errorMessageKey)
+	dst = appendJSONString(dst, err.Message)
+	dst = append(dst, ',')
+	if len(err.Causes) > 0 {
+		dst = appendJSONKey(dst, this.errorCausesKey)
+		dst = append(dst, '[')
+		for i := range err.Causes {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONError(dst, &err.Causes[i])
+		}
+		dst = append(dst, "],"...)
+	}
+	return dst
+}
+
+/*	Место вызова одной строкой либо раздельно файл и строка  */
+func (this *namingType) appendCaller(dst []byte, caller string) []byte {
+	if this.callerKey != "" {
+		dst = appendJSONKey(dst, this.callerKey)
+		dst = appendJSONString(dst, caller)
+		return append(dst, ',')
+	}
+	file, line := caller, ""
+	if index := strings.LastIndexByte(caller, ':'); index >= 0 {
+		file, line = caller[:index], caller[index+1:]
+	}
+	dst = appendJSONKey(dst, this.fileKey)
+	dst = appendJSONString(dst, file)
+	dst = append(dst, ',')
+	if line != "" {
+		dst = appendJSONKey(dst, this.lineKey)
+		dst = append(dst, line...)
+		dst = append(dst, ',')
+	}
+	return dst
+}
+
+/*	[{"func":..,"file":..,"line":..},..]  */
+func appendJSONStack(dst []byte, stack []StackFrameType) []byte {
+	dst = append(dst, '[')
+	for i, frame := range stack {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, "{\"func\":"...)
+		dst = appendJSONString(dst, frame.Function)
+		dst = append(dst, ",\"file\":"...)
+		dst = appendJSONString(dst, frame.File)
+		dst = append(dst, ",\"line\":"...)
+		dst = strconv.AppendInt(dst, int64(frame.Line), 10)
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+/*	{"code":..,"type":..,"message":..,"causes":[..]} - код и тип пишутся только если заданы оба  */
+func appendJSONError(dst []byte, err *ErrorType) []byte {
+	dst = append(dst, '{')
+	if err.Code != 0 && err.Type != "" {
+		dst = append(dst, "\"code\":"...)
+		dst = strconv.AppendUint(dst, uint64(err.Code), 10)
+		dst = append(dst, ",\"type\":"...)
+		dst = appendJSONString(dst, err.Type)
+		dst = append(dst, ',')
+	}
+	dst = append(dst, "\"message\":"...)
+	dst = appendJSONString(dst, err.Message)
+	if len(err.Causes) > 0 {
+		dst = append(dst, ",\"causes\":["...)
+		for i := range err.Causes {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONError(dst, &err.Causes[i])
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,208 @@
+package flogger
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+const logfmtEncoderName = "logfmt"
+
+/*	Формат key=value. Порядок полей такой же как в json: stamp, time, level, статические поля, error.code / error.type /
+**	error.message, caller / func / stack, отсортированные пользовательские поля, msg. Вложенные мапы и слайсы разворачиваются
+**	в плоские ключи через точку (field.key.0=value)  */
+type logfmtEncoderType struct{}
+
+func (this logfmtEncoderType) Encode(dst []byte, record *RecordType) []byte {
+	format := record.getFormat()
+	if format.withVersion == true {
+		dst = append(dst, "v="...)
+		dst = strconv.AppendInt(dst, SchemaVersion, 10)
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, "stamp="...)
+	dst = format.appendStamp(dst, record.Time)
+	dst = append(dst, " time="...)
+	/*	TimeLayout с датой (TimeWithDate, datetime) содержит пробел - такое время берется в кавычки  */
+	dst = appendLogfmtString(dst, format.timeText(record.Time))
+	dst = append(dst, " level="...)
+	dst = appendLogfmtString(dst, record.Level)
+	if record.Static != nil {
+		dst = append(dst, record.Static.logfmt...)
+	}
+	if record.Error != nil {
+		if record.Error.Code != 0 && record.Error.Type != "" {
+			dst = append(dst, " error.code="...)
+			dst = strconv.AppendUint(dst, uint64(record.Error.Code), 10)
+			dst = append(dst, " error.type="...)
+			dst = appendLogfmtString(dst, record.Error.Type)
+		}
+		dst = append(dst, " error.message="...)
+		dst = appendLogfmtString(dst, record.Error.Message)
+		dst = appendLogfmtCauses(dst, record.Error.Causes)
+	}
+	if record.Caller != "" {
+		dst = append(dst, " caller="...)
+		dst = appendLogfmtString(dst, record.Caller)
+	}
+	if record.Function != "" {
+		dst = append(dst, " func="...)
+		dst = appendLogfmtString(dst, record.Function)
+	}
+	if len(record.Stack) > 0 {
+		dst = append(dst, " stack="...)
+		dst = appendLogfmtString(dst, stackToString(record.Stack))
+	}
+	for i := range record.Fields {
+		dst = appendLogfmtTypedField(dst, &record.Fields[i], format)
+	}
+	dst = append(dst, " msg="...)
+	dst = appendLogfmtString(dst, record.Message)
+	return append(dst, '\n')
+}
+
+/*	Дописывает пару key=value с разделителем перед ней. Вложенные значения разворачиваются рекурсивно  */
+func appendLogfmtField(dst []byte, key string, value interface{}, format *formatType) []byte {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) > 0 {
+			keyList := getSortedKeys(typed)
+			for _, nestedKey := range *keyList {
+				dst = appendLogfmtField(dst, key+"."+nestedKey, typed[nestedKey], format)
+			}
+			putKeyList(keyList)
+			return dst
+		}
+	case []FieldType:
+		if len(typed) > 0 {
+			for _, field := range format.orderFields(typed) {
+				field.Key = key + "." + field.Key
+				dst = appendLogfmtTypedField(dst, &field, format)
+			}
+			return dst
+		}
+	case []interface{}:
+		if len(typed) > 0 {
+			for i, nestedValue := range typed {
+				dst = appendLogfmtField(dst, key+"."+strconv.Itoa(i), nestedValue, format)
+			}
+			return dst
+		}
+	}
+
+	dst = append(dst, ' ')
+	dst = appendLogfmtKey(dst, key)
+	dst = append(dst, '=')
+	switch typed := value.(type) {
+	case string:
+		return appendLogfmtString(dst, typed)
+	case map[string]interface{}:
+		return append(dst, "{}"...)
+	case []interface{}:
+		return append(dst, "[]"...)
+	case []FieldType:
+		return append(dst, "{}"...)
+	default:
+		return appendLogfmtValue(dst, value, format)
+	}
+}
+
+/*	Поле записи. Типизированные поля пишутся без упаковки в interface{}  */
+func appendLogfmtTypedField(dst []byte, field *FieldType, format *formatType) []byte {
+	switch field.kind {
+	case fieldKindAny:
+		return appendLogfmtField(dst, field.Key, field.Value, format)
+	case fieldKindString:
+		dst = append(dst, ' ')
+		dst = appendLogfmtKey(dst, field.Key)
+		dst = append(dst, '=')
+		return appendLogfmtString(dst, field.text)
+	}
+	dst = append(dst, ' ')
+	dst = appendLogfmtKey(dst, field.Key)
+	dst = append(dst, '=')
+	start := len(dst)
+	return appendLogfmtJSON(appendFieldValue(dst, field, &valuePathType{format: format}), start)
+}
+
+func appendLogfmtValue(dst []byte, value interface{}, format *formatType) []byte {
+	start := len(dst)
+	return appendLogfmtJSON(appendValue(dst, value, format), start)
+}
+
+/*	Числа, bool и null совпадают с json. Строки из json (время, ошибки, Stringer) пишутся без кавычек если
+**	это возможно, а объекты и массивы (структуры, типизированные мапы) - одной строкой в кавычках.
+**	Значение в json уже дописано в dst начиная со start  */
+func appendLogfmtJSON(dst []byte, start int) []byte {
+	encoded := dst[start:]
+	switch encoded[0] {
+	case '"':
+		inner := encoded[1 : len(encoded)-1]
+		if bytes.IndexByte(inner, '\\') < 0 && isLogfmtNeedQuote(string(inner)) == false {
+			return append(dst[:start], inner...)
+		}
+		return dst
+	case '{', '[':
+		return appendJSONString(dst[:start], string(encoded))
+	default:
+		return dst
+	}
+}
+
+/*	Ключ не может содержать пробелы, '=' и кавычки - такие символы заменяются на '_'  */
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		if b := key[i]; b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+			dst = append(dst, '_')
+		} else {
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+/*	Строка пишется как есть если в ней нет символов требующих кавычек, иначе - в кавычках с экранированием как в json  */
+func appendLogfmtString(dst []byte, value string) []byte {
+	if isLogfmtNeedQuote(value) == true {
+		return appendJSONString(dst, value)
+	}
+	return append(dst, value...)
+}
+
+func isLogfmtNeedQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	var hasMultibyte bool
+	for i := 0; i < len(value); i++ {
+		switch b := value[i]; {
+		case b <= ' ', b == '=', b == '"', b == '\\', b == 0x7f:
+			return true
+		case b >= utf8.RuneSelf:
+			hasMultibyte = true
+		}
+	}
+	return hasMultibyte == true && utf8.ValidString(value) == false
+}
+
+/*	Обернутые ошибки: error.causes.0.code / error.causes.0.type / error.causes.0.message  */
+func appendLogfmtCauses(dst []byte, causes []ErrorType) []byte {
+	for i, cause := range causes {
+		prefix := " error.causes." + strconv.Itoa(i)
+		if cause.Code != 0 && cause.Type != "" {
+			dst = append(dst, prefix...)
+			dst = append(dst, ".code="...)
+			dst = strconv.AppendUint(dst, uint64(cause.Code), 10)
+			dst = append(dst, prefix...)
+			dst = append(dst, ".type="...)
+			dst = appendLogfmtString(dst, cause.Type)
+		}
+		dst = append(dst, prefix...)
+		dst = append(dst, ".message="...)
+		dst = appendLogfmtString(dst, cause.Message)
+	}
+	return dst
+}
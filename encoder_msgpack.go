@@ -0,0 +1,121 @@
+package flogger
+
+import (
+	"encoding/binary"
+)
+
+const (
+	msgpackEncoderName = "msgpack"
+	msgpackLengthSize  = 4
+	msgpackMaxRecord   = 64 << 20 // запись длиннее считается поврежденной - MsgpackToJSON не выделяет под нее память
+)
+
+/*	Бинарный формат MessagePack для нагруженных сервисов. Каждая запись - объект с той же логической схемой что и
+**	в json энкодере (stamp, time, level, статические поля, error, caller / func / stack, отсортированные поля, message),
+**	перед которым пишется его длина (4 байта big endian) - файл можно читать потоком. Перекодировать файл обратно
+**	в json можно функцией MsgpackToJSON  */
+type msgpackEncoderType struct{}
+
+func (this msgpackEncoderType) Encode(dst []byte, record *RecordType) []byte {
+	format := record.getFormat()
+	start := len(dst)
+	dst = append(dst, make([]byte, msgpackLengthSize)...)
+
+	count := 4 + len(record.Fields)
+	if record.Static != nil {
+		count += len(record.Static.Fields)
+	}
+	if record.Error != nil {
+		count++
+	}
+	if record.Caller != "" {
+		count++
+	}
+	if record.Function != "" {
+		count++
+	}
+	if len(record.Stack) > 0 {
+		count++
+	}
+	if format.withVersion == true {
+		count++
+	}
+	dst = appendMsgpackMapHeader(dst, count)
+	if format.withVersion == true {
+		dst = appendMsgpackString(dst, "v")
+		dst = appendMsgpackInt(dst, SchemaVersion)
+	}
+
+	dst = appendMsgpackString(dst, "stamp")
+	dst = appendMsgpackInt(dst, format.stampValue(record.Time))
+	dst = appendMsgpackString(dst, "time")
+	dst = appendMsgpackString(dst, format.timeText(record.Time))
+	dst = appendMsgpackString(dst, "level")
+	dst = appendMsgpackString(dst, record.Level)
+	if record.Static != nil {
+		dst = append(dst, record.Static.msgpack...)
+	}
+	if record.Error != nil {
+		dst = appendMsgpackString(dst, "error")
+		dst = appendMsgpackError(dst, record.Error)
+	}
+	if record.Caller != "" {
+		dst = appendMsgpackString(dst, "caller")
+		dst = appendMsgpackString(dst, record.Caller)
+	}
+	if record.Function != "" {
+		dst = appendMsgpackString(dst, "func")
+		dst = appendMsgpackString(dst, record.Function)
+	}
+	if len(record.Stack) > 0 {
+		dst = appendMsgpackString(dst, "stack")
+		dst = appendMsgpackArrayHeader(dst, len(record.Stack))
+		for _, frame := range record.Stack {
+			dst = appendMsgpackMapHeader(dst, 3)
+			dst = appendMsgpackString(dst, "func")
+			dst = appendMsgpackString(dst, frame.Function)
+			dst = appendMsgpackString(dst, "file")
+			dst = appendMsgpackString(dst, frame.File)
+			dst = appendMsgpackString(dst, "line")
+			dst = appendMsgpackInt(dst, int64(frame.Line))
+		}
+	}
+	path := &valuePathType{format: format}
+	for i := range record.Fields {
+		dst = appendMsgpackString(dst, record.Fields[i].Key)
+		dst = appendMsgpackFieldValue(dst, &record.Fields[i], path)
+	}
+	dst = appendMsgpackString(dst, "message")
+	dst = appendMsgpackString(dst, record.Message)
+
+	binary.BigEndian.PutUint32(dst[start:], uint32(len(dst)-start-msgpackLengthSize))
+	return dst
+}
+
+/*	Схема такая же как в json: код и тип пишутся только если заданы оба  */
+func appendMsgpackError(dst []byte, err *ErrorType) []byte {
+	count := 1
+	if err.Code != 0 && err.Type != "" {
+		count += 2
+	}
+	if len(err.Causes) > 0 {
+		count++
+	}
+	dst = appendMsgpackMapHeader(dst, count)
+	if err.Code != 0 && err.Type != "" {
+		dst = appendMsgpackString(dst, "code")
+		dst = appendMsgpackUint(dst, uint64(err.Code))
+		dst = appendMsgpackString(dst, "type")
+		dst = appendMsgpackString(dst, err.Type)
+	}
+	dst = appendMsgpackString(dst, "message")
+	dst = appendMsgpackString(dst, err.Message)
+	if len(err.Causes) > 0 {
+		dst = appendMsgpackString(dst, "causes")
+		dst = appendMsgpackArrayHeader(dst, len(err.Causes))
+		for i := range err.Causes {
+			dst = appendMsgpackError(dst, &err.Causes[i])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,364 @@
+package flogger
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type upperEncoderType struct{}
+
+func (this upperEncoderType) Encode(dst []byte, record *RecordType) []byte {
+	dst = append(dst, strings.ToUpper(record.Message)...)
+	for _, field := range record.Fields {
+		dst = append(dst, ' ')
+		dst = append(dst, field.Key...)
+	}
+	return append(dst, '\n')
+}
+
+func TestEncoderRegistry(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		encoder, err := getEncoder("")
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		var dto = messageType{
+			Time:     timeType{Time: time.Now()},
+			LogLevel: infoLevel,
+			Fields:   map[string]interface{}{"b": 2, "a": 1},
+			Message:  "message",
+		}
+		record := dto.toRecord(gDefaultFormat, gDefaultLimits)
+		expected, _ := dto.MarshalJSON()
+		if result := encoder.Encode(nil, &record); string(result) != string(expected) {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := getEncoder("unknown"); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+
+	t.Run("custom encoder for file", func(t *testing.T) {
+		RegisterEncoder("upper", upperEncoderType{})
+		loggerConf := newTestConfig(t)
+		loggerConf.DefaultFileEncoder = "upper"
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.Info(map[string]interface{}{"b": 2, "a": 1}, "тестовый %s", "лог")
+
+		if body := stopAndReadLogFile(t, logger, wg, "default"); body != "ТЕСТОВЫЙ ЛОГ a b\n" {
+			t.Errorf("%sFail: got %q%s", RED_BG, body, NO_COLOR)
+		}
+	})
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	now := time.Unix(100500, 0)
+	withDate, err := newFormat(&ConfigType{TimeWithDate: true})
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	testCases := []struct {
+		name     string
+		record   RecordType
+		expected string
+	}{
+		{
+			name: "full record",
+			record: RecordType{
+				Time:  now,
+				Level: warningLevel,
+				Error: &ErrorType{Code: 42, Type: "Business", Message: "cant do \"it\""},
+				Fields: []FieldType{
+					{Key: "arg1", Value: "asds"},
+					{Key: "worker", Value: 1},
+				},
+				Message: "while something",
+			},
+			expected: `stamp=100500 time=` + now.Format("15:04:05") + ` level=WARNING error.code=42 error.type=Business error.message="cant do \"it\"" arg1=asds worker=1 msg="while something"` + "\n",
+		},
+		{
+			name: "nested",
+			record: RecordType{
+				Time:  now,
+				Level: infoLevel,
+				Fields: []FieldType{
+					{Key: "req", Value: map[string]interface{}{"b": []interface{}{1, "x y"}, "a": true}},
+					{Key: "empty", Value: []interface{}{}},
+				},
+				Message: "ok",
+			},
+			expected: `stamp=100500 time=` + now.Format("15:04:05") + ` level=INFO req.a=true req.b.0=1 req.b.1="x y" empty=[] msg=ok` + "\n",
+		},
+		{
+			name: "quoting",
+			record: RecordType{
+				Time:  now,
+				Level: infoLevel,
+				Fields: []FieldType{
+					{Key: "bad key=", Value: ""},
+					{Key: "eq", Value: "a=b"},
+					{Key: "unicode", Value: "юникод"},
+				},
+				Message: "line\nbreak",
+			},
+			expected: `stamp=100500 time=` + now.Format("15:04:05") + ` level=INFO bad_key_="" eq="a=b" unicode=юникод msg="line\nbreak"` + "\n",
+		},
+		{
+			name:     "time with date",
+			record:   RecordType{Time: now, Level: infoLevel, Message: "ok", format: withDate},
+			expected: `stamp=100500 time="` + now.Format("2006-01-02 15:04:05") + `" level=INFO msg=ok` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := (logfmtEncoderType{}).Encode(nil, &tc.record); string(result) != tc.expected {
+				t.Errorf("%sFail:\nexpected %s\ngot      %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	now := time.Unix(100500, 0)
+	record := RecordType{
+		Time:    now,
+		Level:   warningLevel,
+		Error:   &ErrorType{Message: "_error_"},
+		Fields:  []FieldType{{Key: "key", Value: "value"}},
+		Message: "message\nsecond line",
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		expected := `WARNING   ` + now.Format("15:04:05") + ` message\nsecond line error=_error_ key=value` + "\n"
+		if result := (consoleEncoderType{}).Encode(nil, &record); string(result) != expected {
+			t.Errorf("%sFail:\nexpected %q\ngot      %q%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+
+	t.Run("colored", func(t *testing.T) {
+		expected := YELLOW + `WARNING` + NO_COLOR + `   ` + colorGray + now.Format("15:04:05") + NO_COLOR + ` message\nsecond line error=_error_ key=value` + "\n"
+		if result := (consoleEncoderType{isColored: true}).Encode(nil, &record); string(result) != expected {
+			t.Errorf("%sFail:\nexpected %q\ngot      %q%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+}
+
+func TestConsoleOutput(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	loggerConf := newTestConfig(t)
+	loggerConf.EnableServiceDebug = true
+	loggerConf.ConsoleOutput = "stdout"
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	os.Stdout = stdout
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	logger.ServiceDebug(map[string]interface{}{"key": "value"}, "debug message")
+
+	body := stopAndReadLogFile(t, logger, wg, "default")
+	if err := writer.Close(); err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	console, err := io.ReadAll(reader)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+
+	if strings.HasPrefix(string(console), "DEBUG     ") == false || strings.HasSuffix(string(console), " debug message key=value\n") == false {
+		t.Errorf("%sFail: unexpected console output %q%s", RED_BG, console, NO_COLOR)
+	}
+	if strings.HasPrefix(body, "{\"stamp\":") == false {
+		t.Errorf("%sFail: file is expected to stay json %q%s", RED_BG, body, NO_COLOR)
+	}
+}
+
+func TestConsoleLevels(t *testing.T) {
+	consoleLevels, err := parseLevelList("ConsoleLevels", []string{"ERROR", "DEBUG"})
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	componentLevels := &componentLevelsType{}
+	componentRules, err := newComponentRules(map[string]string{"cache": "-DEBUG"})
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	componentLevels.rules.Store(componentRules)
+	mirror := &LoggerType{consoleFile: &fileType{}, componentLevels: componentLevels}
+	own := &LoggerType{consoleFile: &fileType{}, consoleLevels: consoleLevels, hasConsoleLevels: true, componentLevels: componentLevels}
+	cache := *own
+	cache.component = "cache"
+
+	var testCases = []struct {
+		name            string
+		logger          *LoggerType
+		level           LevelType
+		expectedFile    bool
+		expectedConsole bool
+	}{
+		{name: "mirror info", logger: mirror, level: levelInfo, expectedFile: true, expectedConsole: true},
+		{name: "mirror disabled debug", logger: mirror, level: levelServiceDebug, expectedFile: false, expectedConsole: false},
+		{name: "own error", logger: own, level: levelError, expectedFile: true, expectedConsole: true},
+		{name: "own info only in file", logger: own, level: levelInfo, expectedFile: true, expectedConsole: false},
+		{name: "own debug only in console", logger: own, level: levelBusinessDebug, expectedFile: false, expectedConsole: true},
+		{name: "component rule for both", logger: &cache, level: levelServiceDebug, expectedFile: false, expectedConsole: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targets := tc.logger.levelTargets(tc.level)
+			if targets.isFile != tc.expectedFile || targets.isConsole != tc.expectedConsole {
+				t.Errorf("%sFail: expected file %t console %t got %t %t%s", RED_BG, tc.expectedFile, tc.expectedConsole, targets.isFile, targets.isConsole, NO_COLOR)
+			}
+			if enabled := tc.logger.isEnabled(tc.level); enabled != (tc.expectedFile || tc.expectedConsole) {
+				t.Errorf("%sFail: unexpected isEnabled %t%s", RED_BG, enabled, NO_COLOR)
+			}
+		})
+	}
+}
+
+func TestLogfmtValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: "duration", value: 1500 * time.Millisecond, expected: ` key=1.5s`},
+		{name: "quoted string", value: errors.New("not found"), expected: ` key="not found"`},
+		{name: "struct", value: struct{ A int }{A: 1}, expected: ` key="{\"A\":1}"`},
+		{name: "null", value: nil, expected: ` key=null`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := appendLogfmtField(nil, "key", tc.value, gDefaultFormat); string(result) != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+}
+
+type msgpackTestStructType struct {
+	Zeta  string
+	Alpha []int
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	now := time.Unix(100500, 0)
+	static, err := newStaticFields(&ConfigType{StaticFields: map[string]string{"env": "test"}}, gDefaultFormat)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	testCases := []struct {
+		name   string
+		record RecordType
+	}{
+		{
+			name:   "minimal",
+			record: RecordType{Time: now, Level: infoLevel, Message: "message"},
+		},
+		{
+			name: "full record",
+			record: RecordType{
+				Time:     now,
+				Level:    "ERROR",
+				Static:   static,
+				Error:    &ErrorType{Code: 42, Type: "Business", Message: "top", Causes: []ErrorType{{Message: "inner"}}},
+				Caller:   "pkg/file.go:12",
+				Function: "pkg.f",
+				Stack:    []StackFrameType{{Function: "pkg.f", File: "pkg/file.go", Line: 12}},
+				Fields: []FieldType{
+					{Key: "bytes", Value: []byte("abc")},
+					{Key: "float", Value: 1.5},
+					{Key: "int", Value: -100500},
+					{Key: "long", Value: strings.Repeat("x", 300)},
+					{Key: "map", Value: map[string]interface{}{"b": []interface{}{true, nil}, "a": uint64(1 << 40)}},
+					{Key: "struct", Value: msgpackTestStructType{Zeta: "z", Alpha: []int{1, -2}}},
+					{Key: "small", Value: int8(-5)},
+				},
+				Message: "line\nbreak \"quoted\"",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			binary := msgpackEncoderType{}.Encode(nil, &tc.record)
+			binary = msgpackEncoderType{}.Encode(binary, &tc.record)
+			var result strings.Builder
+			if err := MsgpackToJSON(&result, strings.NewReader(string(binary))); err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+				t.FailNow()
+			}
+			expected := jsonEncoderType{}.Encode(nil, &tc.record)
+			expected = jsonEncoderType{}.Encode(expected, &tc.record)
+			if result.String() != string(expected) {
+				t.Errorf("%sFail:\nexpected %s\ngot      %s%s", RED_BG, expected, result.String(), NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("schema version", func(t *testing.T) {
+		format := *gDefaultFormat
+		format.withVersion = true
+		record := testCases[1].record
+		record.format = &format
+		binary := msgpackEncoderType{}.Encode(nil, &record)
+		var result strings.Builder
+		if err := MsgpackToJSON(&result, strings.NewReader(string(binary))); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		expected := jsonEncoderType{}.Encode(nil, &record)
+		if result.String() != string(expected) {
+			t.Errorf("%sFail:\nexpected %s\ngot      %s%s", RED_BG, expected, result.String(), NO_COLOR)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		record := RecordType{Time: now, Level: infoLevel, Message: "message"}
+		binary := msgpackEncoderType{}.Encode(nil, &record)
+		if err := MsgpackToJSON(io.Discard, strings.NewReader(string(binary[:len(binary)-3]))); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+
+	t.Run("corrupt length", func(t *testing.T) {
+		if err := MsgpackToJSON(io.Discard, strings.NewReader("\xff\xff\xff\xff\x80")); err == nil || strings.Contains(err.Error(), "поврежден") == false {
+			t.Errorf("%sFail: expected corrupt length error got %v%s", RED_BG, err, NO_COLOR)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package flogger
 
-type errorType struct {
-	Code    uint   `json:"code,omitempty"` // Данное поле необязательно для уменьшения объема логгируемых данных
-	Type    string `json:"type,omitempty"` // Данное поле необязательно для уменьшения объема логгируемых данных
-	Message string `json:"message"`
+/*	Ошибка в записи (RecordType.Error) - результат errorHandler и обернутые ошибки  */
+type ErrorType struct {
+	Code    uint        `json:"code,omitempty"` // Данное поле необязательно для уменьшения объема логгируемых данных
+	Type    string      `json:"type,omitempty"` // Данное поле необязательно для уменьшения объема логгируемых данных
+	Message string      `json:"message"`
+	Causes  []ErrorType `json:"causes,omitempty"` // Обернутые ошибки (если включен параметр ErrorCausesDepth)
 }
 
 /*	Вопрос: Почему для полиморфизма обработки ошибки вместо интерфейса использованы указатели на функции?
@@ -20,3 +22,38 @@ type errorType struct {
 func defaultErrorHandler(err error) (code uint, errType string, errMessage string) {
 	return 0, "", err.Error()
 }
+
+/*	Обходит дерево обернутых ошибок (errors.Unwrap и Unwrap() []error как у errors.Join) и собирает код, тип и
+**	сообщение каждой ошибки. Дерево сохраняется: ошибки обернутые причиной пишутся в ее Causes. depth - сколько
+**	уровней дерева собирается  */
+func collectCauses(err error, handler func(error) (uint, string, string), depth int) []ErrorType {
+	var causes []ErrorType
+	for _, cause := range unwrapErrors(err) {
+		if cause == nil {
+			continue
+		}
+		code, errType, errMessage := handler(cause)
+		causeError := ErrorType{
+			Code:    code,
+			Type:    errType,
+			Message: errMessage,
+		}
+		if depth > 1 {
+			causeError.Causes = collectCauses(cause, handler, depth-1)
+		}
+		causes = append(causes, causeError)
+	}
+	return causes
+}
+
+func unwrapErrors(err error) []error {
+	switch typed := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := typed.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		return typed.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package flogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+/*	Аналог errors.Join (он появился только в go 1.20)  */
+type testJoinErrorType struct {
+	errs []error
+}
+
+func (this testJoinErrorType) Error() string {
+	return "joined"
+}
+
+func (this testJoinErrorType) Unwrap() []error {
+	return this.errs
+}
+
+type testCodeErrorType struct {
+	code uint
+}
+
+func (this testCodeErrorType) Error() string {
+	return fmt.Sprintf("code %d", this.code)
+}
+
+func testCodeErrorHandler(err error) (uint, string, string) {
+	var codeErr testCodeErrorType
+	if errors.As(err, &codeErr) == true {
+		return codeErr.code, "Business", err.Error()
+	}
+	return 0, "", err.Error()
+}
+
+func TestErrorCauses(t *testing.T) {
+	root := testCodeErrorType{code: 42}
+	err := fmt.Errorf("top: %w", testJoinErrorType{errs: []error{fmt.Errorf("left: %w", root), errors.New("right")}})
+
+	testCases := []struct {
+		name     string
+		depth    int
+		expected string
+	}{
+		{name: "depth 1", depth: 1, expected: "joined"},
+		{name: "depth 2", depth: 2, expected: "joined(left: code 42|right)"},
+		{name: "full tree", depth: 10, expected: "joined(left: code 42(code 42)|right)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := causesTree(collectCauses(err, testCodeErrorHandler, tc.depth)); result != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("record", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.ErrorCausesDepth = 10
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.SetErrorHandler(testCodeErrorHandler)
+		logger.Error(nil, fmt.Errorf("top: %w", root), "with causes")
+		logger.Error(nil, errors.New("plain"), "without causes")
+
+		lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+		if len(lines) != 2 {
+			t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+			t.FailNow()
+		}
+		expected := `"error":{"code":42,"type":"Business","message":"top: code 42","causes":[{"code":42,"type":"Business","message":"code 42"}]},`
+		if strings.Contains(lines[0], expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[0], NO_COLOR)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		}
+		if strings.Contains(lines[1], `"causes"`) == true {
+			t.Errorf("%sFail: causes are not expected in %s%s", RED_BG, lines[1], NO_COLOR)
+		}
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		record := RecordType{
+			Level:   "ERROR",
+			Error:   &ErrorType{Message: "top", Causes: []ErrorType{{Code: 1, Type: "Internal", Message: "inner"}}},
+			Message: "msg",
+		}
+		expected := ` error.message=top error.causes.0.code=1 error.causes.0.type=Internal error.causes.0.message=inner`
+		if result := string(logfmtEncoderType{}.Encode(nil, &record)); strings.Contains(result, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+}
+
+/*	Дерево причин строкой: сообщение(причины|через|черту)  */
+func causesTree(causes []ErrorType) string {
+	var result []string
+	for _, cause := range causes {
+		if len(cause.Causes) > 0 {
+			result = append(result, cause.Message+"("+causesTree(cause.Causes)+")")
+		} else {
+			result = append(result, cause.Message)
+		}
+	}
+	return strings.Join(result, "|")
+}
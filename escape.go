@@ -0,0 +1,68 @@
+package flogger
+
+import (
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+/*	Таблица ASCII символов которые можно писать в json строку без экранирования  */
+var safeASCII = func() [utf8.RuneSelf]bool {
+	var table [utf8.RuneSelf]bool
+	for i := 0x20; i < utf8.RuneSelf; i++ {
+		table[i] = true
+	}
+	table['"'] = false
+	table['\\'] = false
+	return table
+}()
+
+/*	Дописывает строку в кавычках с экранированием по RFC 8259. Невалидный UTF-8 заменяется на �,
+**	U+2028 и U+2029 экранируются (как это делает encoding/json) чтобы строка была валидна и для javascript.
+**	Безопасные участки строки копируются целиком, поэтому на строках без спецсимволов нет лишней работы  */
+func appendJSONString(dst []byte, src string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(src); {
+		if b := src[i]; b < utf8.RuneSelf {
+			if safeASCII[b] == true {
+				i++
+				continue
+			}
+			dst = append(dst, src[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(src[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, src[start:i]...)
+			dst = append(dst, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, src[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, src[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,60 @@
+package flogger
+
+import (
+	"time"
+)
+
+/*	Ключ поля создаваемого конструктором Err. Ключ error занят ошибкой записи  */
+const errFieldKey = "err"
+
+/*	Конструкторы типизированных полей для методов InfoF, ErrorF... В отличие от мапы полей не нужно
+**	аллоцировать мапу и сортировать ее ключи, а значения этих типов сериализуются без рефлексии  */
+
+func String(key string, value string) FieldType {
+	return FieldType{Key: key, kind: fieldKindString, text: value}
+}
+
+func Int(key string, value int) FieldType {
+	return FieldType{Key: key, kind: fieldKindInt64, integer: int64(value)}
+}
+
+func Int64(key string, value int64) FieldType {
+	return FieldType{Key: key, kind: fieldKindInt64, integer: value}
+}
+
+func Bool(key string, value bool) FieldType {
+	var integer int64
+	if value == true {
+		integer = 1
+	}
+	return FieldType{Key: key, kind: fieldKindBool, integer: integer}
+}
+
+/*	Пишется строкой как time.Duration.String() - "1.5s"  */
+func Duration(key string, value time.Duration) FieldType {
+	return FieldType{Key: key, kind: fieldKindDuration, integer: int64(value)}
+}
+
+/*	Пишется строкой в формате RFC 3339 с наносекундами. Хранится как UnixNano и зона - время вне диапазона
+**	UnixNano (1678 - 2262 годы, нулевое время) хранится как есть  */
+func Time(key string, value time.Time) FieldType {
+	nano := value.UnixNano()
+	if time.Unix(0, nano).Equal(value) == false {
+		return FieldType{Key: key, Value: value}
+	}
+	return FieldType{Key: key, kind: fieldKindTime, integer: nano, Value: value.Location()}
+}
+
+/*	Текст ошибки в поле err (nil - null). Текст получается через errorHandler логгера уже в горутине записи и
+**	только для включенного уровня. Для кода и типа ошибки используйте параметр err методов ErrorF, WarningF...  */
+func Err(err error) FieldType {
+	if err == nil {
+		return FieldType{Key: errFieldKey, Value: nil}
+	}
+	return FieldType{Key: errFieldKey, kind: fieldKindError, Value: err}
+}
+
+/*	Значение любого типа - сериализуется так же как значения мапы полей  */
+func Any(key string, value interface{}) FieldType {
+	return FieldType{Key: key, Value: value}
+}
@@ -0,0 +1,199 @@
+package flogger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTypedFields(t *testing.T) {
+	t.Run("encoding", func(t *testing.T) {
+		var dto = messageType{
+			Time:     timeType{Time: time.Unix(100500, 0)},
+			LogLevel: infoLevel,
+			FieldList: []FieldType{
+				String("s", "text"),
+				Int("i", -1),
+				Int64("i64", 1<<40),
+				Bool("b", true),
+				Duration("d", 1500*time.Millisecond),
+				Time("t", time.Date(2022, 12, 18, 23, 59, 58, 0, time.UTC)),
+				Err(errors.New("failed")),
+				Any("any", []string{"x"}),
+			},
+			Message: "message",
+		}
+		expected := `"any":["x"],"b":true,"d":"1.5s","err":"failed","i":-1,"i64":1099511627776,"s":"text","t":"2022-12-18T23:59:58Z","message":"message"}`
+		result, _ := dto.MarshalJSON()
+		if strings.Contains(string(result), expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+
+	t.Run("other encoders", func(t *testing.T) {
+		record := RecordType{
+			Time:    time.Unix(100500, 0),
+			Level:   infoLevel,
+			Fields:  []FieldType{Bool("b", false), Duration("d", time.Second), Int("i", 7), String("s", "a b"), Time("t", time.Date(2022, 12, 18, 23, 59, 58, 0, time.UTC))},
+			Message: "message",
+		}
+		expected := ` b=false d=1s i=7 s="a b" t=2022-12-18T23:59:58Z msg=message`
+		if result := string(logfmtEncoderType{}.Encode(nil, &record)); strings.Contains(result, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+		var result strings.Builder
+		if err := MsgpackToJSON(&result, strings.NewReader(string(msgpackEncoderType{}.Encode(nil, &record)))); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if expected := string(jsonEncoderType{}.Encode(nil, &record)); result.String() != expected {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, result.String(), NO_COLOR)
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		now := time.Date(2022, 12, 18, 23, 59, 58, 1, time.FixedZone("MSK", 3*3600))
+		for _, tc := range []struct {
+			field    FieldType
+			expected interface{}
+		}{
+			{field: String("s", "text"), expected: "text"},
+			{field: Int("i", -1), expected: int64(-1)},
+			{field: Bool("b", true), expected: true},
+			{field: Duration("d", time.Second), expected: time.Second},
+			{field: Any("a", 1.5), expected: 1.5},
+		} {
+			if result := tc.field.Interface(); result != tc.expected {
+				t.Errorf("%sFail: %s expected %v got %v%s", RED_BG, tc.field.Key, tc.expected, result, NO_COLOR)
+			}
+		}
+		if result := Time("t", now).Interface().(time.Time); result.Equal(now) == false || result.Location() != now.Location() {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, now, result, NO_COLOR)
+		}
+		if result := Time("zero", time.Time{}).Interface().(time.Time); result.IsZero() == false {
+			t.Errorf("%sFail: expected zero time got %s%s", RED_BG, result, NO_COLOR)
+		}
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		now := time.Now()
+		var fields [5]FieldType
+		allocs := testing.AllocsPerRun(100, func() {
+			fields[0] = Int("i", 100500)
+			fields[1] = Int64("i64", 1<<40)
+			fields[2] = Duration("d", time.Minute)
+			fields[3] = Time("t", now)
+			fields[4] = Err(errors.New("failed"))
+		})
+		/*	Аллоцирует только errors.New  */
+		if allocs > 1 {
+			t.Errorf("%sFail: expected 1 allocation got %v%s", RED_BG, allocs, NO_COLOR)
+		}
+	})
+
+	t.Run("err through error handler", func(t *testing.T) {
+		newTestConfig(t)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		var calls int
+		logger.SetErrorHandler(func(err error) (uint, string, string) {
+			calls++
+			return 0, "", "handled: " + err.Error()
+		})
+		logger.InfoF("enabled", Err(errors.New("failed")))
+		logger.ServiceDebugF("disabled", Err(errors.New("skipped")))
+
+		body := stopAndReadLogFile(t, logger, wg, "default")
+		if expected := `"err":"handled: failed","message":"enabled"}`; strings.Contains(body, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, body, NO_COLOR)
+		}
+		if calls != 1 {
+			t.Errorf("%sFail: expected 1 error handler call got %d%s", RED_BG, calls, NO_COLOR)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		limits := &limitsType{maxStringLength: 4, stats: &statsType{}}
+		var dto = messageType{
+			Time:      timeType{Time: time.Unix(100500, 0)},
+			LogLevel:  infoLevel,
+			FieldList: []FieldType{String("s", "abcdef"), Err(errors.New("failed"))},
+			Message:   "message",
+		}
+		limits.apply(&dto)
+		record := dto.toRecord(gDefaultFormat, limits)
+		expected := `"err":"fail…[truncated 2 bytes]","s":"abcd…[truncated 2 bytes]",`
+		if result := string(jsonEncoderType{}.Encode(nil, &record)); strings.Contains(result, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+
+	t.Run("list wins over map", func(t *testing.T) {
+		result := gDefaultFormat.mergeFields(sortFields(map[string]interface{}{"a": 1, "c": 3}), []FieldType{Int("c", 30), Int("b", 20)})
+		var keys []string
+		for _, field := range result {
+			keys = append(keys, field.Key)
+		}
+		if strings.Join(keys, ",") != "a,b,c" || result[2].Interface() != int64(30) {
+			t.Errorf("%sFail: unexpected fields %v%s", RED_BG, result, NO_COLOR)
+		}
+	})
+
+	t.Run("logger methods", func(t *testing.T) {
+		newTestConfig(t)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.InfoF("100% done", String("worker", "w1"), Int("count", 2))
+		logger.ErrorF(errors.New("cant do"), "failed")
+		logger.ServiceDebugF("disabled")
+
+		lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+		if len(lines) != 2 {
+			t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+			t.FailNow()
+		}
+		if expected := `"level":"INFO","count":2,"worker":"w1","message":"100% done"}`; strings.Contains(lines[0], expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[0], NO_COLOR)
+		}
+		if expected := `"error":{"message":"cant do"},"message":"failed"}`; strings.Contains(lines[1], expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[1], NO_COLOR)
+		}
+	})
+}
+
+/*	go test -bench Fields -benchmem  */
+func BenchmarkFieldsMap(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var dto = messageType{
+			Fields:  map[string]interface{}{"worker": 1, "arg1": "asds", "arg2": "fdsjkfhdsfjkh"},
+			Message: "while something",
+		}
+		record := dto.toRecord(gDefaultFormat, gDefaultLimits)
+		_ = jsonEncoderType{}.Encode(make([]byte, 0, 256), &record)
+	}
+}
+
+func BenchmarkFieldsTyped(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var dto = messageType{
+			FieldList: []FieldType{Int("worker", 1), String("arg1", "asds"), String("arg2", "fdsjkfhdsfjkh")},
+			Message:   "while something",
+		}
+		record := dto.toRecord(gDefaultFormat, gDefaultLimits)
+		_ = jsonEncoderType{}.Encode(make([]byte, 0, 256), &record)
+	}
+}
@@ -13,71 +13,62 @@ type fileType struct {
 	serviceName   string
 	logFolder     string
 	permissions   string
-	maxHours      uint
+	rotation      utils.RotationIntervalType
 	maxBufSize    uint
 	writeChanSize uint
-	fileTypeName  string // default / additional
+	fileTypeName  string // default / important / query / console
+	isConsole     bool   // вывод в stdout / stderr - файл не меняется и не закрывается
 	fileName      string
-	currentDate   time.Time
+	currentBucket time.Time // начало интервала к которому относится текущий файл
 	osFile        *os.File
-	bmu           *sync.Mutex                        // буфферный мьютекс
-	buf           []messageType                      // в этом буффере хранятся сформированные сообщения. Буффер отправляется на запись в файл либо при его заполнении либо по таймауту (тикер)
-	writeChan     chan []messageType                 // буфферизированный канал передачи между объектом логгера который наполняет буффер и горутиной записи в файл
-	errorHandler  func(error) (uint, string, string) // функция извлечения из ошибки ее кода, типа и сообщения
+	encoder       IEncoder           // формат вывода в файл
+	format        *formatType        // формат записей логгера
+	limits        *limitsType        // ограничения размеров записи логгера
+	bmu           *sync.Mutex        // буфферный мьютекс
+	buf           []messageType      // в этом буффере хранятся сформированные сообщения. Буффер отправляется на запись в файл либо при его заполнении либо по таймауту (тикер)
+	writeChan     chan []messageType // буфферизированный канал передачи между объектом логгера который наполняет буффер и горутиной записи в файл
 }
 
-func newFile(fileTypeName string, conf *ConfigType) *fileType {
+func newFile(fileTypeName, encoderName string, conf *ConfigType, format *formatType, limits *limitsType) (*fileType, error) {
+	rotation, err := newRotationInterval(conf)
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := getEncoder(encoderName)
+	if err != nil {
+		return nil, err
+	}
 	return &fileType{
 		serviceName:   conf.ServiceName,
 		logFolder:     conf.LogFolder,
 		permissions:   conf.Permissions,
-		maxHours:      conf.MaxHoursToChangeLogFile,
+		rotation:      rotation,
+		encoder:       encoder,
+		format:        format,
+		limits:        limits,
 		maxBufSize:    conf.MaxBufSize,
 		writeChanSize: conf.WriteChanSize,
 		fileTypeName:  fileTypeName,
 		bmu:           &sync.Mutex{},
 		buf:           make([]messageType, 0, int(conf.MaxBufSize)+1),
 		writeChan:     make(chan []messageType, int(conf.WriteChanSize)),
-		errorHandler:  defaultErrorHandler,
-	}
+	}, nil
 }
 
-func (this *fileType) SetErrorHandler(errorHandler func(error) (uint, string, string)) {
-	this.errorHandler = errorHandler
-}
-
-func (this *fileType) addToBuffer(level string, err error, fields map[string]interface{}, message string) {
-	now := time.Now()
-	var cerr *errorType
-	if err != nil {
-		if this.errorHandler != nil {
-			code, errType, errMessage := this.errorHandler(err)
-			cerr = &errorType{
-				Code:    code,
-				Type:    errType,
-				Message: errMessage,
-			}
-		} else {
-			println("Warning: file logger found case errorHandler == nil")
-			cerr = &errorType{
-				Code:    0,
-				Type:    "",
-				Message: err.Error(),
-			}
+/*	Параметр RotationInterval имеет приоритет. Если он не задан - работает старый параметр MaxHoursToChangeLogFile  */
+func newRotationInterval(conf *ConfigType) (utils.RotationIntervalType, error) {
+	if conf.RotationInterval == "" {
+		if conf.MaxHoursToChangeLogFile == 0 {
+			return utils.RotationIntervalType{}, fmt.Errorf("Параметр MaxHoursToChangeLogFile конфигурации модуля flogger должен быть больше нуля если не задан RotationInterval")
 		}
+		return utils.NewHoursRotationInterval(conf.MaxHoursToChangeLogFile), nil
 	}
+	return utils.ParseRotationInterval(conf.RotationInterval)
+}
 
+func (this *fileType) addToBuffer(message messageType) {
 	this.bmu.Lock()
-	this.buf = append(this.buf, messageType{
-		Timestamp: now.Unix(),
-		Time: timeType{
-			Time: now,
-		},
-		LogLevel: level,
-		Error:    cerr,
-		Fields:   fields,
-		Message:  message,
-	})
+	this.buf = append(this.buf, message)
 
 	/*	Проверяю заполненность буффера - возможно его пора отправить в файл  */
 	if len(this.buf) >= int(this.maxBufSize) {
@@ -104,10 +95,21 @@ func (this *fileType) writeFromBufIfNotEmpty() {
 	}
 	this.bmu.Unlock()
 	if len(cpyBuf) > 0 {
-		this.write(convertBufToBite(cpyBuf))
+		this.write(this.convertBufToBite(cpyBuf))
 	}
 }
 
+/*	Сериализует буффер сообщений энкодером этого файла  */
+func (this *fileType) convertBufToBite(cpyBuf []messageType) []byte {
+	var dst []byte
+	var record RecordType
+	for _, message := range cpyBuf {
+		record = message.toRecord(this.format, this.limits)
+		dst = this.limits.encodeLimited(dst, this.encoder, &record)
+	}
+	return dst
+}
+
 func (this *fileType) write(message []byte) {
 	if err := this.changeLogFileIfItNeeded(); err != nil {
 		fmt.Fprintf(os.Stderr, "Не смог сменить файл логгирования %s", err)
@@ -130,7 +132,10 @@ func (this *fileType) write(message []byte) {
 /*	Меняет файл в который записывается логгирование в случае если уже сменилась дата
 **	Использует мьютекс, поэтому выполняется горутинобезопасно  */
 func (this *fileType) changeLogFileIfItNeeded() error {
-	if utils.IsSameDateAndHour(this.currentDate, time.Now(), this.maxHours) == false {
+	if this.isConsole == true {
+		return nil
+	}
+	if this.rotation.BucketStart(time.Now()).Equal(this.currentBucket) == false {
 		if err := this.setNewLogFile(); err != nil {
 			return err
 		}
@@ -146,12 +151,8 @@ func (this *fileType) setNewLogFile() error {
 	}
 
 	/*	Открываю новый файл  */
-	this.currentDate = time.Now()
-	var hour int
-	if this.maxHours < 24 {
-		hour = utils.CalcHour(this.currentDate.Hour(), int(this.maxHours))
-	}
-	this.fileName = fmt.Sprintf("%s_%s_%d-%02d-%02d_%02d.log", this.serviceName, this.fileTypeName, this.currentDate.Year(), this.currentDate.Month(), this.currentDate.Day(), hour)
+	this.currentBucket = this.rotation.BucketStart(time.Now())
+	this.fileName = this.makeFileName(this.currentBucket)
 	file, err := utils.OpenOrCreateNewFile(this.logFolder, this.fileName, this.permissions)
 	if err != nil {
 		return err
@@ -160,8 +161,16 @@ func (this *fileType) setNewLogFile() error {
 	return nil
 }
 
+/*	Название файла содержит начало интервала (минуты только для интервалов меньше часа)  */
+func (this *fileType) makeFileName(bucket time.Time) string {
+	if this.rotation.HasMinutes() == true {
+		return fmt.Sprintf("%s_%s_%d-%02d-%02d_%02d-%02d.log", this.serviceName, this.fileTypeName, bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), bucket.Minute())
+	}
+	return fmt.Sprintf("%s_%s_%d-%02d-%02d_%02d.log", this.serviceName, this.fileTypeName, bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour())
+}
+
 func (this *fileType) Close() error {
-	if this.osFile != nil {
+	if this.osFile != nil && this.isConsole == false {
 		if err := this.osFile.Close(); err != nil {
 			return fmt.Errorf("Не смог закрыть лог файл %w", err)
 		}
@@ -0,0 +1,196 @@
+package flogger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTimeLayout  = "15:04:05"
+	dateLayout         = "2006-01-02 "
+	timeLayoutRFC3339  = "rfc3339nano"
+	timeLayoutDateTime = "datetime"
+
+	floatFormatShortest = "g"
+	floatFormatFixed    = "f"
+	floatFormatExponent = "e"
+
+	nonFiniteAsString = "string"
+	nonFiniteAsNull   = "null"
+)
+
+/*	Настройки формата записей общие для всех энкодеров. Формируются один раз в NewLogger из конфига и дальше
+**	не меняются - у каждого логгера свой формат, горутина записи читает его без блокировок  */
+type formatType struct {
+	stampUnit       time.Duration // s / ms / µs / ns
+	timeLayout      string
+	keepFieldsOrder bool // поля переданные упорядоченным списком ([]FieldType) не сортируются
+	floatFormat     byte // формат strconv.AppendFloat
+	floatPrecision  int
+	nonFiniteAsNull bool              // NaN и ±Inf пишутся как null, иначе - строкой "NaN" / "+Inf" / "-Inf"
+	callerWithFunc  bool              // вместе с caller пишется имя функции
+	withVersion     bool              // в начале записи пишется поле v с версией формата
+	naming          *namingType       // профиль имен полей json энкодера
+	static          *StaticFieldsType // статические поля уже сериализованные в этом формате, nil если их нет
+}
+
+/*	Формат по умолчанию совпадает с исторически сложившимся: stamp в секундах, время без даты.
+**	Используется для записей вне логгера (MarshalJSON, записи собранные пользовательским кодом)  */
+var gDefaultFormat = &formatType{
+	stampUnit:      time.Second,
+	timeLayout:     defaultTimeLayout,
+	floatFormat:    'g',
+	floatPrecision: -1,
+	naming:         gNamings[namingNative],
+}
+
+func newFormat(conf *ConfigType) (*formatType, error) {
+	format := &formatType{}
+
+	switch conf.TimestampUnit {
+	case "", "s":
+		format.stampUnit = time.Second
+	case "ms":
+		format.stampUnit = time.Millisecond
+	case "us", "µs":
+		format.stampUnit = time.Microsecond
+	case "ns":
+		format.stampUnit = time.Nanosecond
+	default:
+		return nil, fmt.Errorf("Параметр TimestampUnit конфигурации модуля flogger может быть только s, ms, us, ns (задан %s)", conf.TimestampUnit)
+	}
+
+	switch conf.TimeLayout {
+	case "":
+		format.timeLayout = defaultTimeLayout
+	case timeLayoutRFC3339:
+		format.timeLayout = time.RFC3339Nano
+	case timeLayoutDateTime:
+		format.timeLayout = "2006-01-02 15:04:05.000000"
+	default:
+		format.timeLayout = conf.TimeLayout
+	}
+	/*	В rfc3339nano и datetime дата есть всегда  */
+	if conf.TimeWithDate == true && conf.TimeLayout != timeLayoutRFC3339 && conf.TimeLayout != timeLayoutDateTime {
+		format.timeLayout = dateLayout + format.timeLayout
+	}
+	format.keepFieldsOrder = conf.KeepFieldsOrder
+	format.callerWithFunc = conf.CallerWithFunc
+	format.withVersion = conf.SchemaVersionField
+
+	naming, err := parseNaming(conf.FieldNaming)
+	if err != nil {
+		return nil, err
+	}
+	format.naming = naming
+	if err := checkNaming(conf, naming); err != nil {
+		return nil, err
+	}
+
+	/*	Точность имеет смысл только для фиксированного и экспоненциального формата, отрицательная - минимально
+	**	необходимое количество знаков для точного восстановления числа. Ноль - параметр не задан, иначе
+	**	в формате f все числа молча округлялись бы до целых  */
+	format.floatPrecision = conf.FloatPrecision
+	if format.floatPrecision == 0 {
+		format.floatPrecision = -1
+	}
+	switch conf.FloatFormat {
+	case "", floatFormatShortest:
+		format.floatFormat = 'g'
+		format.floatPrecision = -1
+	case floatFormatFixed:
+		format.floatFormat = 'f'
+	case floatFormatExponent:
+		format.floatFormat = 'E'
+	default:
+		return nil, fmt.Errorf("Параметр FloatFormat конфигурации модуля flogger может быть только g, f, e (задан %s)", conf.FloatFormat)
+	}
+
+	switch conf.FloatNonFinite {
+	case "", nonFiniteAsString:
+		format.nonFiniteAsNull = false
+	case nonFiniteAsNull:
+		format.nonFiniteAsNull = true
+	default:
+		return nil, fmt.Errorf("Параметр FloatNonFinite конфигурации модуля flogger может быть только string или null (задан %s)", conf.FloatNonFinite)
+	}
+	return format, nil
+}
+
+/*	Профиль имен реализован только в json энкодере - с другими энкодерами он молча не действовал бы.
+**	Опубликованная схема записи (и версия в поле v) описывает только собственный формат модуля  */
+func checkNaming(conf *ConfigType, naming *namingType) error {
+	if naming == gNamings[namingNative] {
+		return nil
+	}
+	if conf.SchemaVersionField == true {
+		return fmt.Errorf("Параметр SchemaVersionField конфигурации модуля flogger нельзя включить вместе с FieldNaming %s", conf.FieldNaming)
+	}
+	type encoderParamType struct {
+		param       string
+		encoderName string
+		isUsed      bool
+	}
+	consoleEncoder := conf.ConsoleEncoder
+	if consoleEncoder == "" {
+		consoleEncoder = consoleEncoderName
+	}
+	for _, encoder := range []encoderParamType{
+		{param: "DefaultFileEncoder", encoderName: conf.DefaultFileEncoder, isUsed: true},
+		{param: "ImportantFileEncoder", encoderName: conf.ImportantFileEncoder, isUsed: conf.EnableFileForImportant},
+		{param: "QueryFileEncoder", encoderName: conf.QueryFileEncoder, isUsed: conf.EnableFileForQuery},
+		{param: "ConsoleEncoder", encoderName: consoleEncoder, isUsed: conf.ConsoleOutput != ""},
+	} {
+		if encoder.isUsed == true && encoder.encoderName != "" && encoder.encoderName != defaultEncoderName {
+			return fmt.Errorf("Параметр FieldNaming конфигурации модуля flogger действует только для энкодера json (%s: %s)", encoder.param, encoder.encoderName)
+		}
+	}
+	return nil
+}
+
+/*	Дописывает stamp в единицах заданных параметром TimestampUnit  */
+func (this *formatType) appendStamp(dst []byte, now time.Time) []byte {
+	return strconv.AppendInt(dst, this.stampValue(now), 10)
+}
+
+func (this *formatType) stampValue(now time.Time) int64 {
+	switch this.stampUnit {
+	case time.Millisecond:
+		return now.UnixMilli()
+	case time.Microsecond:
+		return now.UnixMicro()
+	case time.Nanosecond:
+		return now.UnixNano()
+	default:
+		return now.Unix()
+	}
+}
+
+/*	NaN и ±Inf не бывают в json - они пишутся строкой либо null (параметр FloatNonFinite)  */
+func (this *formatType) appendFloat(dst []byte, value float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(value):
+		if this.nonFiniteAsNull == true {
+			return append(dst, "null"...)
+		}
+		return append(dst, "\"NaN\""...)
+	case math.IsInf(value, 1):
+		if this.nonFiniteAsNull == true {
+			return append(dst, "null"...)
+		}
+		return append(dst, "\"+Inf\""...)
+	case math.IsInf(value, -1):
+		if this.nonFiniteAsNull == true {
+			return append(dst, "null"...)
+		}
+		return append(dst, "\"-Inf\""...)
+	}
+	return strconv.AppendFloat(dst, value, this.floatFormat, this.floatPrecision, bitSize)
+}
+
+/*	Время строкой по параметру TimeLayout (без кавычек)  */
+func (this *formatType) timeText(now time.Time) string {
+	return now.Format(this.timeLayout)
+}
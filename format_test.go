@@ -0,0 +1,195 @@
+package flogger
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimestampFormat(t *testing.T) {
+	now := time.Date(2022, 12, 18, 23, 59, 58, 123456789, time.UTC)
+	testCases := []struct {
+		name     string
+		conf     ConfigType
+		expected string
+	}{
+		{
+			name:     "default",
+			conf:     ConfigType{},
+			expected: `{"stamp":1671407998,"time":"23:59:58",`,
+		},
+		{
+			name:     "milliseconds with date",
+			conf:     ConfigType{TimestampUnit: "ms", TimeWithDate: true},
+			expected: `{"stamp":1671407998123,"time":"2022-12-18 23:59:58",`,
+		},
+		{
+			name:     "microseconds datetime",
+			conf:     ConfigType{TimestampUnit: "µs", TimeLayout: "datetime", TimeWithDate: true},
+			expected: `{"stamp":1671407998123456,"time":"2022-12-18 23:59:58.123456",`,
+		},
+		{
+			name:     "nanoseconds rfc3339nano",
+			conf:     ConfigType{TimestampUnit: "ns", TimeLayout: "rfc3339nano"},
+			expected: `{"stamp":1671407998123456789,"time":"2022-12-18T23:59:58.123456789Z",`,
+		},
+		{
+			name:     "custom layout",
+			conf:     ConfigType{TimeLayout: "15:04:05.000"},
+			expected: `{"stamp":1671407998,"time":"23:59:58.123",`,
+		},
+		{
+			name:     "layout with quotes",
+			conf:     ConfigType{TimeLayout: "\"15\"\t04"},
+			expected: `{"stamp":1671407998,"time":"\"23\"\t59",`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := newFormat(&tc.conf)
+			if err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+				t.FailNow()
+			}
+
+			record := RecordType{Time: now, Level: infoLevel, Message: "message", format: format}
+			result := string(jsonEncoderType{}.Encode(nil, &record))
+			if len(result) < len(tc.expected) || result[:len(tc.expected)] != tc.expected {
+				t.Errorf("%sFail: expected prefix %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("invalid unit", func(t *testing.T) {
+		if _, err := newFormat(&ConfigType{TimestampUnit: "minutes"}); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+}
+
+func TestFloatFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     ConfigType
+		value    interface{}
+		expected string
+	}{
+		{name: "shortest", conf: ConfigType{}, value: 0.5, expected: `0.5`},
+		{name: "shortest float32", conf: ConfigType{FloatFormat: "g"}, value: float32(0.1), expected: `0.1`},
+		{name: "shortest large", conf: ConfigType{}, value: 1e21, expected: `1e+21`},
+		{name: "fixed", conf: ConfigType{FloatFormat: "f", FloatPrecision: 3}, value: 2.0 / 3, expected: `0.667`},
+		{name: "fixed unset precision", conf: ConfigType{FloatFormat: "f"}, value: 2.5, expected: `2.5`},
+		{name: "exponent", conf: ConfigType{FloatFormat: "e", FloatPrecision: -1}, value: 0.5, expected: `5E-01`},
+		{name: "NaN as string", conf: ConfigType{}, value: math.NaN(), expected: `"NaN"`},
+		{name: "+Inf as string", conf: ConfigType{FloatNonFinite: "string"}, value: math.Inf(1), expected: `"+Inf"`},
+		{name: "-Inf as null", conf: ConfigType{FloatNonFinite: "null"}, value: float32(math.Inf(-1)), expected: `null`},
+		{name: "NaN in struct as null", conf: ConfigType{FloatNonFinite: "null"}, value: struct{ F float64 }{F: math.NaN()}, expected: `{"F":null}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := newFormat(&tc.conf)
+			if err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+				t.FailNow()
+			}
+
+			result := appendValue(nil, tc.value, format)
+			if string(result) != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+			if json.Valid(result) == false {
+				t.Errorf("%sFail: invalid json %s%s", RED_BG, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("invalid format", func(t *testing.T) {
+		if _, err := newFormat(&ConfigType{FloatFormat: "x"}); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+		if _, err := newFormat(&ConfigType{FloatNonFinite: "zero"}); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+}
+
+func TestFieldNaming(t *testing.T) {
+	record := RecordType{
+		Time:     time.Date(2022, 12, 18, 23, 59, 58, 123456789, time.UTC),
+		Level:    queryLevel,
+		Error:    &ErrorType{Code: 42, Type: "Business", Message: "cant do"},
+		Caller:   "pkg/file.go:12",
+		Function: "pkg.f",
+		Stack:    []StackFrameType{{Function: "pkg.f", File: "pkg/file.go", Line: 12}},
+		Fields:   []FieldType{{Key: "a", Value: 1}},
+		Message:  "message",
+	}
+	testCases := []struct {
+		name     string
+		naming   string
+		expected string
+	}{
+		{
+			name:     "native",
+			naming:   "native",
+			expected: `{"stamp":1671407998,"time":"23:59:58","level":"QUERY","error":{"code":42,"type":"Business","message":"cant do"},"caller":"pkg/file.go:12","func":"pkg.f","stack":[{"func":"pkg.f","file":"pkg/file.go","line":12}],"a":1,"message":"message"}` + "\n",
+		},
+		{
+			name:     "ecs",
+			naming:   "ecs",
+			expected: `{"@timestamp":"2022-12-18T23:59:58.123456789Z","log.level":"QUERY","log.syslog.severity.code":7,"error.code":42,"error.type":"Business","error.message":"cant do","log.origin.file.name":"pkg/file.go","log.origin.file.line":12,"log.origin.function":"pkg.f","error.stack_trace":"pkg.f (pkg/file.go:12)","a":1,"message":"message"}` + "\n",
+		},
+		{
+			name:     "otel",
+			naming:   "OTel",
+			expected: `{"time_unix_nano":1671407998123456789,"severity_text":"QUERY","severity_number":6,"error.code":42,"exception.type":"Business","exception.message":"cant do","code.filepath":"pkg/file.go","code.lineno":12,"code.function":"pkg.f","exception.stacktrace":"pkg.f (pkg/file.go:12)","a":1,"body":"message"}` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := newFormat(&ConfigType{FieldNaming: tc.naming, CallerWithFunc: true})
+			if err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+				t.FailNow()
+			}
+			record := record
+			record.format = format
+			if result := string(jsonEncoderType{}.Encode(nil, &record)); result != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("severity for every level", func(t *testing.T) {
+		for level := LevelType(0); level < levelCount; level++ {
+			if _, isExists := gSyslogSeverities[level.String()]; isExists == false {
+				t.Errorf("%sFail: no syslog severity for %s%s", RED_BG, level, NO_COLOR)
+			}
+			if _, isExists := gOTelSeverities[level.String()]; isExists == false {
+				t.Errorf("%sFail: no otel severity for %s%s", RED_BG, level, NO_COLOR)
+			}
+		}
+	})
+
+	t.Run("invalid naming", func(t *testing.T) {
+		for _, conf := range []ConfigType{
+			{FieldNaming: "gelf"},
+			{FieldNaming: "ecs", DefaultFileEncoder: "logfmt"},
+			{FieldNaming: "ecs", EnableFileForQuery: true, QueryFileEncoder: "msgpack"},
+			{FieldNaming: "otel", ConsoleOutput: "stdout"},
+			{FieldNaming: "otel", SchemaVersionField: true},
+		} {
+			if _, err := newFormat(&conf); err == nil {
+				t.Errorf("%sFail: expected error for %+v%s", RED_BG, conf, NO_COLOR)
+			}
+		}
+		conf := ConfigType{FieldNaming: "ecs", QueryFileEncoder: "msgpack", ConsoleOutput: "stdout", ConsoleEncoder: "json"}
+		if _, err := newFormat(&conf); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		}
+	})
+}
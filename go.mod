@@ -1,7 +1,10 @@
 module github.com/GlobchanskyDenis/file_logger
 
-go 1.19
+go 1.21
 
-require github.com/GlobchanskyDenis/yaml v0.0.4
+require (
+	github.com/GlobchanskyDenis/yaml v0.0.4
+	github.com/go-logr/logr v1.4.2
+)
 
 require gopkg.in/yaml.v2 v2.4.0 // indirect
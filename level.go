@@ -0,0 +1,76 @@
+package flogger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*	Идентификатор уровня. Нужен потому что ServiceDebug и BusinessDebug пишутся одинаково (DEBUG),
+**	но включаются раздельно. Снаружи задается константами Level* (StdLogger, Writer)  */
+type LevelType uint8
+
+const (
+	levelFatal LevelType = iota
+	levelError
+	levelWarning
+	levelInfo
+	levelServiceDebug
+	levelBusinessDebug
+	levelQuery
+	levelImportant
+	levelDecision
+	levelCount
+)
+
+/*	Уровни для методов которые принимают уровень параметром (StdLogger, Writer)  */
+const (
+	LevelFatal         LevelType = levelFatal
+	LevelError         LevelType = levelError
+	LevelWarning       LevelType = levelWarning
+	LevelInfo          LevelType = levelInfo
+	LevelServiceDebug  LevelType = levelServiceDebug
+	LevelBusinessDebug LevelType = levelBusinessDebug
+	LevelQuery         LevelType = levelQuery
+	LevelImportant     LevelType = levelImportant
+	LevelDecision      LevelType = levelDecision
+)
+
+var gLevelNames = [levelCount]string{
+	levelFatal:         fatalLevel,
+	levelError:         errorLevel,
+	levelWarning:       warningLevel,
+	levelInfo:          infoLevel,
+	levelServiceDebug:  serviceDebugLevel,
+	levelBusinessDebug: businessDebugLevel,
+	levelQuery:         queryLevel,
+	levelImportant:     importantLevel,
+	levelDecision:      decisionLevel,
+}
+
+/*	Неизвестный уровень (вне констант Level*) пишется как LEVEL(номер)  */
+func (this LevelType) String() string {
+	if this >= levelCount {
+		return "LEVEL(" + strconv.Itoa(int(this)) + ")"
+	}
+	return gLevelNames[this]
+}
+
+/*	Список уровней из конфига (FATAL, ERROR, WARNING, INFO, DEBUG, QUERY, IMPORTANT, DECISION) в набор флагов.
+**	DEBUG включает и ServiceDebug и BusinessDebug  */
+func parseLevelList(paramName string, levelList []string) ([levelCount]bool, error) {
+	var result [levelCount]bool
+	for _, levelName := range levelList {
+		var isFound bool
+		for level := LevelType(0); level < levelCount; level++ {
+			if strings.EqualFold(levelName, gLevelNames[level]) == true {
+				result[level] = true
+				isFound = true
+			}
+		}
+		if isFound == false {
+			return result, fmt.Errorf("Параметр %s конфигурации модуля flogger содержит неизвестный уровень %s", paramName, levelName)
+		}
+	}
+	return result, nil
+}
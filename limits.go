@@ -0,0 +1,306 @@
+package flogger
+
+import (
+	"encoding/base64"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const (
+	truncatedFieldsKey = "truncated_fields" // количество отброшенных по MaxFieldsCount полей
+	truncatedRecordKey = "truncated"        // заменяет все поля записи превысившей MaxRecordSize
+	truncatedNested    = "…[truncated nested value]"
+)
+
+/*	Ограничения размеров записи (0 - без ограничения). Сообщение, ошибка, строки и вложенность ограничиваются
+**	еще при логгировании - чтобы огромные значения не копились в буффере записей. Количество полей
+**	ограничивается после объединения полей из всех источников (toRecord), а размер сериализованной
+**	записи - при сериализации. И то и другое - в горутине записи  */
+type limitsType struct {
+	maxMessageLength int
+	maxStringLength  int
+	maxFieldsCount   int
+	maxNestingDepth  int
+	maxRecordSize    int
+	stats            *statsType
+}
+
+/*	Счетчики срабатывания ограничений  */
+type StatsType struct {
+	TruncatedMessages uint64 // сообщения обрезанные по MaxMessageLength
+	TruncatedStrings  uint64 // строковые (и []byte) значения полей обрезанные по MaxStringFieldLength
+	DroppedFields     uint64 // поля отброшенные по MaxFieldsCount
+	TruncatedNested   uint64 // вложенные значения замененные по MaxNestingDepth
+	TruncatedRecords  uint64 // записи поля которых отброшены по MaxRecordSize
+	TruncatedBytes    uint64 // всего обрезано байт строк и записей
+}
+
+type statsType struct {
+	truncatedMessages atomic.Uint64
+	truncatedStrings  atomic.Uint64
+	droppedFields     atomic.Uint64
+	truncatedNested   atomic.Uint64
+	truncatedRecords  atomic.Uint64
+	truncatedBytes    atomic.Uint64
+}
+
+/*	Ограничения по умолчанию - их нет. Для записей вне логгера (MarshalJSON)  */
+var gDefaultLimits = &limitsType{
+	stats: &statsType{},
+}
+
+func newLimits(conf *ConfigType) *limitsType {
+	return &limitsType{
+		maxMessageLength: int(conf.MaxMessageLength),
+		maxStringLength:  int(conf.MaxStringFieldLength),
+		maxFieldsCount:   int(conf.MaxFieldsCount),
+		maxNestingDepth:  int(conf.MaxNestingDepth),
+		maxRecordSize:    int(conf.MaxRecordSize),
+		stats:            &statsType{},
+	}
+}
+
+func (this *limitsType) getStats() StatsType {
+	return StatsType{
+		TruncatedMessages: this.stats.truncatedMessages.Load(),
+		TruncatedStrings:  this.stats.truncatedStrings.Load(),
+		DroppedFields:     this.stats.droppedFields.Load(),
+		TruncatedNested:   this.stats.truncatedNested.Load(),
+		TruncatedRecords:  this.stats.truncatedRecords.Load(),
+		TruncatedBytes:    this.stats.truncatedBytes.Load(),
+	}
+}
+
+/*	Ограничивает сообщение, ошибку и поля записи. Мапа полей принадлежит вызывающему коду, поэтому
+**	при изменениях создается ее копия. Ошибка создана логгером для этой записи и меняется на месте  */
+func (this *limitsType) apply(message *messageType) {
+	if this.maxMessageLength > 0 && len(message.Message) > this.maxMessageLength {
+		message.Message = this.truncateString(message.Message, this.maxMessageLength)
+		this.stats.truncatedMessages.Add(1)
+	}
+	if message.Error != nil && this.maxStringLength > 0 {
+		this.limitError(message.Error)
+	}
+	if len(message.Fields) > 0 && (this.maxStringLength > 0 || this.maxNestingDepth > 0) {
+		message.Fields = this.limitFields(message.Fields)
+	}
+	if len(message.FieldList) > 0 && (this.maxStringLength > 0 || this.maxNestingDepth > 0) {
+		message.FieldList = this.limitFieldList(message.FieldList)
+	}
+	if len(message.ContextFields) > 0 && (this.maxStringLength > 0 || this.maxNestingDepth > 0) {
+		message.ContextFields = this.limitFieldList(message.ContextFields)
+	}
+}
+
+/*	Сообщение ошибки и ее причин ограничивается как строковое значение поля  */
+func (this *limitsType) limitError(err *ErrorType) {
+	if limited, isChanged := this.limitValue(err.Message, 1); isChanged == true {
+		err.Message = limited.(string)
+	}
+	for i := range err.Causes {
+		this.limitError(&err.Causes[i])
+	}
+}
+
+func (this *limitsType) limitFields(fields map[string]interface{}) map[string]interface{} {
+	var result map[string]interface{}
+	for key, value := range fields {
+		limited, isChanged := this.limitValue(value, 1)
+		if isChanged == false {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]interface{}, len(fields))
+			for key, value := range fields {
+				result[key] = value
+			}
+		}
+		result[key] = limited
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+/*	Слайс полей принадлежит вызывающему коду - при изменениях создается копия  */
+func (this *limitsType) limitFieldList(fields []FieldType) []FieldType {
+	/*	Сам список - нулевой уровень вложенности, значения полей - первый  */
+	if limited, isChanged := this.limitValue(fields, 0); isChanged == true {
+		return limited.([]FieldType)
+	}
+	return fields
+}
+
+/*	Поля записи уже объединены из всех источников и упорядочены - остаются первые maxFieldsCount,
+**	после них пишется количество отброшенных. Слайс может принадлежать дочернему логгеру - создается копия  */
+func (this *limitsType) limitFieldsCount(fields []FieldType) []FieldType {
+	if this.maxFieldsCount == 0 || len(fields) <= this.maxFieldsCount {
+		return fields
+	}
+	dropped := len(fields) - this.maxFieldsCount
+	limited := append(make([]FieldType, 0, this.maxFieldsCount+1), fields[:this.maxFieldsCount]...)
+	this.stats.droppedFields.Add(uint64(dropped))
+	return append(limited, Int(truncatedFieldsKey, dropped))
+}
+
+/*	Возвращает ограниченное значение и признак того что оно изменилось. depth - уровень вложенности контейнера
+**	(значение поля - первый уровень). Ограничиваются только строки, []byte и контейнеры которые логгер кодирует
+**	без рефлексии, остальное ограничивается размером записи (MaxRecordSize)  */
+func (this *limitsType) limitValue(value interface{}, depth int) (interface{}, bool) {
+	switch typed := value.(type) {
+	case string:
+		if this.maxStringLength > 0 && len(typed) > this.maxStringLength {
+			this.stats.truncatedStrings.Add(1)
+			return this.truncateString(typed, this.maxStringLength), true
+		}
+	case []byte:
+		/*	[]byte кодируется в base64 - ограничивается длина этой строки  */
+		if this.maxStringLength > 0 && base64.StdEncoding.EncodedLen(len(typed)) > this.maxStringLength {
+			keep := this.maxStringLength / 4 * 3
+			this.stats.truncatedStrings.Add(1)
+			this.stats.truncatedBytes.Add(uint64(len(typed) - keep))
+			return base64.StdEncoding.EncodeToString(typed[:keep]) + truncatedMarker(len(typed)-keep), true
+		}
+	case []string:
+		if this.isTooDeep(depth) == true {
+			return truncatedNested, true
+		}
+		var result []string
+		for i, item := range typed {
+			if limited, isChanged := this.limitValue(item, depth+1); isChanged == true {
+				if result == nil {
+					result = append([]string(nil), typed...)
+				}
+				result[i] = limited.(string)
+			}
+		}
+		if result != nil {
+			return result, true
+		}
+	case []interface{}:
+		if this.isTooDeep(depth) == true {
+			return truncatedNested, true
+		}
+		var result []interface{}
+		for i, item := range typed {
+			if limited, isChanged := this.limitValue(item, depth+1); isChanged == true {
+				if result == nil {
+					result = append([]interface{}(nil), typed...)
+				}
+				result[i] = limited
+			}
+		}
+		if result != nil {
+			return result, true
+		}
+	case []FieldType:
+		if this.isTooDeep(depth) == true {
+			return truncatedNested, true
+		}
+		var result []FieldType
+		for i := range typed {
+			if limited, isChanged := this.limitField(typed[i], depth+1); isChanged == true {
+				if result == nil {
+					result = append([]FieldType(nil), typed...)
+				}
+				result[i] = limited
+			}
+		}
+		if result != nil {
+			return result, true
+		}
+	case map[string]interface{}:
+		if this.isTooDeep(depth) == true {
+			return truncatedNested, true
+		}
+		var result map[string]interface{}
+		for key, item := range typed {
+			if limited, isChanged := this.limitValue(item, depth+1); isChanged == true {
+				if result == nil {
+					result = make(map[string]interface{}, len(typed))
+					for key, item := range typed {
+						result[key] = item
+					}
+				}
+				result[key] = limited
+			}
+		}
+		if result != nil {
+			return result, true
+		}
+	}
+	return value, false
+}
+
+/*	У типизированных полей ограничиваются только строки (String и текст ошибки Err)  */
+func (this *limitsType) limitField(field FieldType, depth int) (FieldType, bool) {
+	switch field.kind {
+	case fieldKindAny:
+		if limited, isChanged := this.limitValue(field.Value, depth); isChanged == true {
+			field.Value = limited
+			return field, true
+		}
+	case fieldKindString:
+		if this.maxStringLength > 0 && len(field.text) > this.maxStringLength {
+			this.stats.truncatedStrings.Add(1)
+			field.text = this.truncateString(field.text, this.maxStringLength)
+			return field, true
+		}
+	}
+	return field, false
+}
+
+func (this *limitsType) isTooDeep(depth int) bool {
+	if this.maxNestingDepth > 0 && depth > this.maxNestingDepth {
+		this.stats.truncatedNested.Add(1)
+		return true
+	}
+	return false
+}
+
+/*	Обрезает строку по границе символа UTF-8 и дописывает маркер. Строка копируется - иначе
+**	обрезанная часть продолжит занимать память  */
+func (this *limitsType) truncateString(value string, maxLength int) string {
+	cut := maxLength
+	for cut > 0 && utf8.RuneStart(value[cut]) == false {
+		cut--
+	}
+	this.stats.truncatedBytes.Add(uint64(len(value) - cut))
+	return string(append([]byte(value[:cut]), truncatedMarker(len(value)-cut)...))
+}
+
+func truncatedMarker(bytesCount int) string {
+	return "…[truncated " + strconv.Itoa(bytesCount) + " bytes]"
+}
+
+/*	Запись превысившая MaxRecordSize сериализуется заново: вместо полей и стека пишется маркер, а если
+**	и этого не хватило - дополнительно обрезается сообщение  */
+func (this *limitsType) encodeLimited(dst []byte, encoder IEncoder, record *RecordType) []byte {
+	start := len(dst)
+	dst = encoder.Encode(dst, record)
+	size := len(dst) - start
+	if this.maxRecordSize == 0 || size <= this.maxRecordSize {
+		return dst
+	}
+	this.stats.truncatedRecords.Add(1)
+
+	limited := *record
+	limited.Stack = nil
+	limited.Fields = []FieldType{{Key: truncatedRecordKey, Value: ""}}
+	reducedSize := len(encoder.Encode(dst[:start], &limited)) - start
+	limited.Fields[0].Value = truncatedMarker(size - reducedSize)
+	this.stats.truncatedBytes.Add(uint64(size - reducedSize))
+
+	reducedSize = len(encoder.Encode(dst[:start], &limited)) - start
+	if excess := reducedSize - this.maxRecordSize; excess > 0 && len(limited.Message) > 0 {
+		/*	С запасом на маркер сообщения  */
+		keep := len(limited.Message) - excess - len(truncatedMarker(len(limited.Message)))
+		if keep < 0 {
+			keep = 0
+		}
+		limited.Message = this.truncateString(limited.Message, keep)
+	}
+	return encoder.Encode(dst[:start], &limited)
+}
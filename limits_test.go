@@ -0,0 +1,134 @@
+package flogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitValue(t *testing.T) {
+	limits := &limitsType{maxStringLength: 10, maxNestingDepth: 2, stats: &statsType{}}
+	testCases := []struct {
+		name      string
+		value     interface{}
+		expected  string
+		isChanged bool
+	}{
+		{name: "short string", value: "short", expected: `"short"`, isChanged: false},
+		{name: "long string", value: strings.Repeat("a", 25), expected: `"aaaaaaaaaa…[truncated 15 bytes]"`, isChanged: true},
+		{name: "utf8 boundary", value: "ффффффф", expected: `"ффффф…[truncated 4 bytes]"`, isChanged: true},
+		{name: "bytes", value: []byte(strings.Repeat("b", 20)), expected: `"YmJiYmJi…[truncated 14 bytes]"`, isChanged: true},
+		{name: "nested string", value: map[string]interface{}{"a": []interface{}{strings.Repeat("c", 11)}}, expected: `{"a":["cccccccccc…[truncated 1 bytes]"]}`, isChanged: true},
+		{name: "too deep", value: []interface{}{[]interface{}{[]string{"x"}}, 1}, expected: `[["…[truncated nested value]"],1]`, isChanged: true},
+		{name: "number", value: 100500, expected: `100500`, isChanged: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limited, isChanged := limits.limitValue(tc.value, 1)
+			if isChanged != tc.isChanged {
+				t.Errorf("%sFail: expected changed %t got %t%s", RED_BG, tc.isChanged, isChanged, NO_COLOR)
+			}
+			if result := string(appendValue(nil, limited, gDefaultFormat)); result != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("source is not modified", func(t *testing.T) {
+		source := map[string]interface{}{"a": strings.Repeat("a", 20)}
+		limits.limitFields(source)
+		if len(source["a"].(string)) != 20 {
+			t.Errorf("%sFail: source map was modified%s", RED_BG, NO_COLOR)
+		}
+	})
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("message and fields", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.MaxMessageLength = 5
+		loggerConf.MaxFieldsCount = 2
+		loggerConf.MaxStringFieldLength = 3
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.Info(map[string]interface{}{"a": "abcdef", "b": 2, "c": 3, "d": 4}, "long message")
+
+		body := stopAndReadLogFile(t, logger, wg, "default")
+		expected := `"a":"abc…[truncated 3 bytes]","b":2,"truncated_fields":2,"message":"long …[truncated 7 bytes]"}`
+		if strings.Contains(body, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, body, NO_COLOR)
+		}
+		stats := logger.Stats()
+		if stats.TruncatedMessages != 1 || stats.TruncatedStrings != 1 || stats.DroppedFields != 2 || stats.TruncatedBytes != 10 {
+			t.Errorf("%sFail: unexpected stats %+v%s", RED_BG, stats, NO_COLOR)
+		}
+	})
+
+	t.Run("fields count after merge and error", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.MaxFieldsCount = 2
+		loggerConf.MaxStringFieldLength = 3
+		loggerConf.ErrorCausesDepth = 1
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		cause := errors.New("inner cause")
+		logger.With(map[string]interface{}{"a": 1, "b": 2}).ErrorF(fmt.Errorf("outer: %w", cause), "message", Int("c", 3))
+
+		body := stopAndReadLogFile(t, logger, wg, "default")
+		expected := `"error":{"message":"out…[truncated 15 bytes]","causes":[{"message":"inn…[truncated 8 bytes]"}]},"a":1,"b":2,"truncated_fields":1,"message":"message"}`
+		if strings.Contains(body, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, body, NO_COLOR)
+		}
+		if stats := logger.Stats(); stats.DroppedFields != 1 || stats.TruncatedStrings != 2 {
+			t.Errorf("%sFail: unexpected stats %+v%s", RED_BG, stats, NO_COLOR)
+		}
+	})
+
+	t.Run("record size", func(t *testing.T) {
+		limits := &limitsType{maxRecordSize: 150, stats: &statsType{}}
+		record := RecordType{
+			Time:    time.Unix(100500, 0),
+			Level:   infoLevel,
+			Fields:  []FieldType{{Key: "body", Value: strings.Repeat("x", 1000)}},
+			Message: "message",
+		}
+		result := limits.encodeLimited(nil, jsonEncoderType{}, &record)
+		if len(result) > 150 {
+			t.Errorf("%sFail: record is too long %d%s", RED_BG, len(result), NO_COLOR)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(result, &decoded); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		}
+		if marker, _ := decoded[truncatedRecordKey].(string); strings.HasPrefix(marker, "…[truncated ") == false || decoded["message"] != "message" {
+			t.Errorf("%sFail: unexpected record %s%s", RED_BG, result, NO_COLOR)
+		}
+
+		record.Fields = nil
+		record.Message = strings.Repeat("m", 1000)
+		result = limits.encodeLimited(nil, jsonEncoderType{}, &record)
+		if len(result) > 150 || strings.Contains(string(result), "…[truncated ") == false {
+			t.Errorf("%sFail: unexpected record %d %s%s", RED_BG, len(result), result, NO_COLOR)
+		}
+		if stats := limits.getStats(); stats.TruncatedRecords != 2 {
+			t.Errorf("%sFail: unexpected stats %+v%s", RED_BG, stats, NO_COLOR)
+		}
+	})
+}
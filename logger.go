@@ -1,6 +1,7 @@
 package flogger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -33,6 +34,21 @@ type LoggerType struct {
 	defaultFile         *fileType
 	importantFile       *fileType
 	queryFile           *fileType
+	consoleFile         *fileType
+	consoleLevels       [levelCount]bool                   // уровни консоли если задан параметр ConsoleLevels
+	hasConsoleLevels    bool                               // иначе в консоль выводятся те же уровни что и в файлы
+	errorHandler        func(error) (uint, string, string) // функция извлечения из ошибки ее кода, типа и сообщения
+	callerLevels        [levelCount]bool                   // уровни для которых запоминается место вызова
+	callerSkip          int                                // дополнительные фреймы для пользовательских оберток над логгером
+	stackLevels         [levelCount]bool                   // уровни для которых запоминается стек
+	stackDepth          int
+	causesDepth         int                  // глубина разворачивания обернутых ошибок, 0 - выключено
+	format              *formatType          // формат записей, у каждого логгера свой
+	limits              *limitsType          // ограничения размеров записи
+	boundFields         []FieldType          // поля дочернего логгера (With), отсортированы по ключу
+	isChild             bool                 // дочерний логгер не владеет файлами и не может их закрыть
+	component           string               // имя компонента (Named)
+	componentLevels     *componentLevelsType // настройки уровней по компонентам, общие для всех дочерних логгеров
 }
 
 var _ IServiceLogger = (*LoggerType)(nil)
@@ -68,13 +84,57 @@ func NewLogger(wg *sync.WaitGroup) (*LoggerType, error) {
 	}
 	conf := GetConfig()
 
+	format, err := newFormat(conf)
+	if err != nil {
+		return nil, err
+	}
+	if format.static, err = newStaticFields(conf, format); err != nil {
+		return nil, err
+	}
+	limits := newLimits(conf)
+
+	defaultFile, err := newFile("default", conf.DefaultFileEncoder, conf, format, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	callerLevels, err := parseLevelList("CallerLevels", conf.CallerLevels)
+	if err != nil {
+		return nil, err
+	}
+
+	stackLevels, err := parseLevelList("StackLevels", conf.StackLevels)
+	if err != nil {
+		return nil, err
+	}
+	stackDepth := int(conf.StackDepth)
+	if stackDepth == 0 {
+		stackDepth = defaultStackDepth
+	}
+
+	componentRules, err := newComponentRules(conf.ComponentLevels)
+	if err != nil {
+		return nil, err
+	}
+	componentLevels := &componentLevelsType{}
+	componentLevels.rules.Store(componentRules)
+
 	logger := &LoggerType{
 		enableServiceDebug:  conf.EnableServiceDebug,
 		enableBusinessDebug: conf.EnableBusinessDebug,
 		enableQuery:         conf.EnableQuery,
 		enableImportant:     conf.EnableImportant,
 		enableDecision:      conf.EnableDecision,
-		defaultFile:         newFile("default", conf),
+		defaultFile:         defaultFile,
+		errorHandler:        defaultErrorHandler,
+		callerLevels:        callerLevels,
+		callerSkip:          int(conf.CallerSkip),
+		stackLevels:         stackLevels,
+		stackDepth:          stackDepth,
+		causesDepth:         int(conf.ErrorCausesDepth),
+		format:              format,
+		limits:              limits,
+		componentLevels:     componentLevels,
 	}
 
 	if err := logger.defaultFile.setNewLogFile(); err != nil {
@@ -83,7 +143,9 @@ func NewLogger(wg *sync.WaitGroup) (*LoggerType, error) {
 
 	/*	Тут переопределяется файл сразу для 3-х уровней логгирования - Important Error Fatal */
 	if conf.EnableFileForImportant == true {
-		logger.importantFile = newFile("important", conf)
+		if logger.importantFile, err = newFile("important", conf.ImportantFileEncoder, conf, format, limits); err != nil {
+			return nil, err
+		}
 		if err := logger.importantFile.setNewLogFile(); err != nil {
 			return nil, err
 		}
@@ -91,12 +153,27 @@ func NewLogger(wg *sync.WaitGroup) (*LoggerType, error) {
 
 	/*	Тут переопределяется файл для уровня логгирования Query */
 	if conf.EnableFileForQuery == true {
-		logger.queryFile = newFile("query", conf)
+		if logger.queryFile, err = newFile("query", conf.QueryFileEncoder, conf, format, limits); err != nil {
+			return nil, err
+		}
 		if err := logger.queryFile.setNewLogFile(); err != nil {
 			return nil, err
 		}
 	}
 
+	/*	Тут включается дублирование записей в консоль (для локальной разработки) */
+	if conf.ConsoleOutput != "" {
+		if logger.consoleFile, err = newConsoleFile(conf, format, limits); err != nil {
+			return nil, err
+		}
+		if len(conf.ConsoleLevels) > 0 {
+			if logger.consoleLevels, err = parseLevelList("ConsoleLevels", conf.ConsoleLevels); err != nil {
+				return nil, err
+			}
+			logger.hasConsoleLevels = true
+		}
+	}
+
 	gLogger = logger
 	go logger.writeLoopAsync(wg, conf.FileWriteDurationSeconds)
 
@@ -115,82 +192,443 @@ func (this *LoggerType) SetImportantMonitoringTrigger(trigger IMonitoringTrigger
 	this.importantTrigger = trigger
 }
 
+/*	Счетчики срабатывания ограничений размеров записи (MaxMessageLength, MaxRecordSize...)  */
+func (this *LoggerType) Stats() StatsType {
+	return this.limits.getStats()
+}
+
 func (this *LoggerType) SetErrorHandler(errorHandler func(error) (uint, string, string)) {
-	this.defaultFile.SetErrorHandler(errorHandler)
-	if this.importantFile != nil {
-		this.importantFile.SetErrorHandler(errorHandler)
-	}
+	this.errorHandler = errorHandler
 }
 
 func (this *LoggerType) Fatal(fields map[string]interface{}, err error, msg string, args ...interface{}) {
-	if this.importantFile != nil {
-		this.importantFile.addToBuffer(fatalLevel, err, fields, fmt.Sprintf(msg, args...))
-	}
-	this.defaultFile.addToBuffer(fatalLevel, err, fields, fmt.Sprintf(msg, args...))
-	if this.fatalTrigger != nil {
-		go this.fatalTrigger.Trig()
+	targets := this.levelTargets(levelFatal)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
 	}
+	this.log(entryType{level: levelFatal, targets: targets, err: err, fields: fields, message: msg})
 }
 
 func (this *LoggerType) Error(fields map[string]interface{}, err error, msg string, args ...interface{}) {
-	if this.importantFile != nil {
-		this.importantFile.addToBuffer(errorLevel, err, fields, fmt.Sprintf(msg, args...))
-	}
-	this.defaultFile.addToBuffer(errorLevel, err, fields, fmt.Sprintf(msg, args...))
-	if this.errorTrigger != nil {
-		go this.errorTrigger.Trig()
+	targets := this.levelTargets(levelError)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
 	}
+	this.log(entryType{level: levelError, targets: targets, err: err, fields: fields, message: msg})
 }
 
 func (this *LoggerType) Warning(fields map[string]interface{}, err error, msg string, args ...interface{}) {
-	this.defaultFile.addToBuffer(warningLevel, err, fields, fmt.Sprintf(msg, args...))
+	targets := this.levelTargets(levelWarning)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{level: levelWarning, targets: targets, err: err, fields: fields, message: msg})
 }
 
 func (this *LoggerType) Info(fields map[string]interface{}, msg string, args ...interface{}) {
-	this.defaultFile.addToBuffer(infoLevel, nil, fields, fmt.Sprintf(msg, args...))
+	targets := this.levelTargets(levelInfo)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{level: levelInfo, targets: targets, fields: fields, message: msg})
 }
 
 func (this *LoggerType) ServiceDebug(fields map[string]interface{}, msg string, args ...interface{}) {
-	if this.enableServiceDebug == true {
-		this.defaultFile.addToBuffer(serviceDebugLevel, nil, fields, fmt.Sprintf(msg, args...))
+	targets := this.levelTargets(levelServiceDebug)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
 	}
+	this.log(entryType{level: levelServiceDebug, targets: targets, fields: fields, message: msg})
 }
 
 func (this *LoggerType) BusinessDebug(fields map[string]interface{}, msg string, args ...interface{}) {
-	if this.enableBusinessDebug == true {
-		this.defaultFile.addToBuffer(businessDebugLevel, nil, fields, fmt.Sprintf(msg, args...))
+	targets := this.levelTargets(levelBusinessDebug)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
 	}
+	this.log(entryType{level: levelBusinessDebug, targets: targets, fields: fields, message: msg})
 }
 
 func (this *LoggerType) Query(fields map[string]interface{}, msg string, args ...interface{}) {
-	if this.enableQuery == true {
+	targets := this.levelTargets(levelQuery)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{level: levelQuery, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) Important(fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelImportant)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{level: levelImportant, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) Decision(fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelDecision)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{level: levelDecision, targets: targets, fields: fields, message: msg})
+}
+
+/*	Методы с контекстом - в запись добавляются поля извлеченные из контекста (см. RegisterContextExtractor)  */
+
+func (this *LoggerType) FatalCtx(ctx context.Context, fields map[string]interface{}, err error, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelFatal)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelFatal, targets: targets, err: err, fields: fields, message: msg})
+}
+
+func (this *LoggerType) ErrorCtx(ctx context.Context, fields map[string]interface{}, err error, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelError)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelError, targets: targets, err: err, fields: fields, message: msg})
+}
+
+func (this *LoggerType) WarningCtx(ctx context.Context, fields map[string]interface{}, err error, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelWarning)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelWarning, targets: targets, err: err, fields: fields, message: msg})
+}
+
+func (this *LoggerType) InfoCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelInfo)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelInfo, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) ServiceDebugCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelServiceDebug)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelServiceDebug, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) BusinessDebugCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelBusinessDebug)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelBusinessDebug, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) QueryCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelQuery)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelQuery, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) ImportantCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelImportant)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelImportant, targets: targets, fields: fields, message: msg})
+}
+
+func (this *LoggerType) DecisionCtx(ctx context.Context, fields map[string]interface{}, msg string, args ...interface{}) {
+	targets := this.levelTargets(levelDecision)
+	if targets.isEnabled() == true {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	this.log(entryType{ctx: ctx, level: levelDecision, targets: targets, fields: fields, message: msg})
+}
+
+/*	Методы с типизированными полями (String, Int, Duration...) - без мапы и без рефлексии при сериализации.
+**	Сообщение пишется как есть (не форматная строка)  */
+
+func (this *LoggerType) FatalF(err error, msg string, fields ...FieldType) {
+	this.log(entryType{level: levelFatal, targets: this.levelTargets(levelFatal), err: err, fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) ErrorF(err error, msg string, fields ...FieldType) {
+	this.log(entryType{level: levelError, targets: this.levelTargets(levelError), err: err, fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) WarningF(err error, msg string, fields ...FieldType) {
+	this.log(entryType{level: levelWarning, targets: this.levelTargets(levelWarning), err: err, fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) InfoF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelInfo, targets: this.levelTargets(levelInfo), fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) ServiceDebugF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelServiceDebug, targets: this.levelTargets(levelServiceDebug), fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) BusinessDebugF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelBusinessDebug, targets: this.levelTargets(levelBusinessDebug), fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) QueryF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelQuery, targets: this.levelTargets(levelQuery), fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) ImportantF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelImportant, targets: this.levelTargets(levelImportant), fieldList: fields, message: msg})
+}
+
+func (this *LoggerType) DecisionF(msg string, fields ...FieldType) {
+	this.log(entryType{level: levelDecision, targets: this.levelTargets(levelDecision), fieldList: fields, message: msg})
+}
+
+/*	Ленивые методы - build вызывается только для включенного уровня, поэтому построение дорогих полей
+**	и сообщения ничего не стоит если уровень выключен. Сообщение пишется как есть (не форматная строка)  */
+
+func (this *LoggerType) FatalLazy(err error, build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelFatal, targets: this.levelTargets(levelFatal), err: err, build: build})
+}
+
+func (this *LoggerType) ErrorLazy(err error, build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelError, targets: this.levelTargets(levelError), err: err, build: build})
+}
+
+func (this *LoggerType) WarningLazy(err error, build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelWarning, targets: this.levelTargets(levelWarning), err: err, build: build})
+}
+
+func (this *LoggerType) InfoLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelInfo, targets: this.levelTargets(levelInfo), build: build})
+}
+
+func (this *LoggerType) ServiceDebugLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelServiceDebug, targets: this.levelTargets(levelServiceDebug), build: build})
+}
+
+func (this *LoggerType) BusinessDebugLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelBusinessDebug, targets: this.levelTargets(levelBusinessDebug), build: build})
+}
+
+func (this *LoggerType) QueryLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelQuery, targets: this.levelTargets(levelQuery), build: build})
+}
+
+func (this *LoggerType) ImportantLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelImportant, targets: this.levelTargets(levelImportant), build: build})
+}
+
+func (this *LoggerType) DecisionLazy(build func() (map[string]interface{}, string)) {
+	this.log(entryType{level: levelDecision, targets: this.levelTargets(levelDecision), build: build})
+}
+
+/*	Параметры одной записи - от публичного метода (или адаптера) до log  */
+type entryType struct {
+	ctx       context.Context
+	level     LevelType
+	err       error
+	fields    map[string]interface{}
+	fieldList []FieldType
+	targets   targetsType                             // куда попадает запись - вычисляется один раз на вызов
+	message   string                                  // уже отформатированное сообщение
+	build     func() (map[string]interface{}, string) // ленивые методы - поля и сообщение строятся только для включенного уровня
+	callerPC  uintptr                                 // место вызова известно заранее (запись slog)
+	time      time.Time                               // время записи известно заранее (запись slog)
+	skip      int                                     // дополнительные фреймы адаптера между log и пользовательским кодом
+}
+
+/*	Общая часть всех уровней логгирования. Публичные методы вызывают ее напрямую, поэтому от нее до
+**	пользовательского кода всегда одинаковое количество фреймов (см. captureCaller). Сообщение уже
+**	отформатировано - fmt.Sprintf вызывается в самих публичных методах (один раз и только для включенного
+**	уровня), чтобы go vet проверял форматные строки в местах их вызова. Куда попадает запись тоже решает
+**	вызывающий (entry.targets) - та же проверка, по которой форматировалось сообщение  */
+func (this *LoggerType) log(entry entryType) {
+	if entry.targets.isEnabled() == true {
+		var callerPC uintptr
+		if this.callerLevels[entry.level] == true {
+			callerPC = entry.callerPC
+			if callerPC == 0 {
+				callerPC = captureCaller(this.callerSkip + entry.skip)
+			}
+		}
+		if entry.build != nil {
+			entry.fields, entry.message = entry.build()
+		}
+		message := this.newMessage(entry.level, entry.err, entry.fields, entry.message, callerPC)
+		if entry.time.IsZero() == false {
+			message.Time.Time = entry.time
+		}
+		message.FieldList = entry.fieldList
+		message.BoundFields = this.boundFields
+		message.ContextFields = extractContextFields(entry.ctx)
+		this.limits.apply(&message)
+		if this.stackLevels[entry.level] == true {
+			message.StackPCs = captureStack(entry.err, this.callerSkip+entry.skip, this.stackDepth)
+		}
+		this.addMessage(entry.level, message, entry.targets)
+	}
+	this.trig(entry.level)
+}
+
+/*	Отправляет запись в буфферы файлов уровня и (или) в консоль  */
+func (this *LoggerType) addMessage(level LevelType, message messageType, targets targetsType) {
+	if targets.isConsole == true {
+		this.consoleFile.addToBuffer(message)
+	}
+	if targets.isFile == false {
+		return
+	}
+	switch level {
+	case levelFatal, levelError, levelImportant:
+		/*	Эти уровни дублируются в файл important (если он включен)  */
+		if this.importantFile != nil {
+			this.importantFile.addToBuffer(message)
+		}
+		this.defaultFile.addToBuffer(message)
+	case levelQuery:
+		/*	Этот уровень пишется в файл query вместо дефолтного (если он включен)  */
 		if this.queryFile != nil {
-			this.queryFile.addToBuffer(queryLevel, nil, fields, fmt.Sprintf(msg, args...))
+			this.queryFile.addToBuffer(message)
 		} else {
-			this.defaultFile.addToBuffer(queryLevel, nil, fields, fmt.Sprintf(msg, args...))
+			this.defaultFile.addToBuffer(message)
 		}
+	default:
+		this.defaultFile.addToBuffer(message)
 	}
 }
 
-func (this *LoggerType) Important(fields map[string]interface{}, msg string, args ...interface{}) {
-	if this.enableImportant == true {
-		if this.importantFile != nil {
-			this.importantFile.addToBuffer(importantLevel, nil, fields, fmt.Sprintf(msg, args...))
+/*	Триггер Important срабатывает даже если сам уровень выключен  */
+func (this *LoggerType) trig(level LevelType) {
+	switch level {
+	case levelFatal:
+		if this.fatalTrigger != nil {
+			go this.fatalTrigger.Trig()
+		}
+	case levelError:
+		if this.errorTrigger != nil {
+			go this.errorTrigger.Trig()
+		}
+	case levelImportant:
+		if this.importantTrigger != nil {
+			go this.importantTrigger.Trig()
 		}
-		this.defaultFile.addToBuffer(importantLevel, nil, fields, fmt.Sprintf(msg, args...))
 	}
-	if this.importantTrigger != nil {
-		go this.importantTrigger.Trig()
+}
+
+/*	Куда попадает запись уровня. Вычисляется один раз на вызов и передается в log - правила компонентов
+**	(SetComponentLevels) могут поменяться между проверкой уровня в публичном методе и записью  */
+type targetsType struct {
+	isFile    bool
+	isConsole bool
+}
+
+/*	Уровень включен если запись попадает хотя бы в файлы или в консоль  */
+func (this targetsType) isEnabled() bool {
+	return this.isFile == true || this.isConsole == true
+}
+
+func (this *LoggerType) isEnabled(level LevelType) bool {
+	return this.levelTargets(level).isEnabled()
+}
+
+/*	Настройки компонента (Named) точнее всего остального и действуют и на файлы и на консоль. Иначе в файлы
+**	пишутся уровни включенные параметрами Enable*, а в консоль - уровни ConsoleLevels (если он задан)  */
+func (this *LoggerType) levelTargets(level LevelType) targetsType {
+	if this.component != "" {
+		if state := this.componentLevels.levelState(this.component, level); state != 0 {
+			return targetsType{isFile: state > 0, isConsole: state > 0 && this.consoleFile != nil}
+		}
+	}
+	var targets = targetsType{isFile: this.isFileLevel(level)}
+	if this.consoleFile != nil {
+		targets.isConsole = targets.isFile
+		if this.hasConsoleLevels == true {
+			targets.isConsole = this.consoleLevels[level]
+		}
 	}
+	return targets
 }
 
-func (this *LoggerType) Decision(fields map[string]interface{}, msg string, args ...interface{}) {
-	if this.enableDecision == true {
-		this.defaultFile.addToBuffer(decisionLevel, nil, fields, fmt.Sprintf(msg, args...))
+func (this *LoggerType) isFileLevel(level LevelType) bool {
+	switch level {
+	case levelServiceDebug:
+		return this.enableServiceDebug
+	case levelBusinessDebug:
+		return this.enableBusinessDebug
+	case levelQuery:
+		return this.enableQuery
+	case levelImportant:
+		return this.enableImportant
+	case levelDecision:
+		return this.enableDecision
+	default:
+		return true
+	}
+}
+
+/*	Запись формируется один раз и затем копируется в буфферы всех файлов в которые она попадает  */
+func (this *LoggerType) newMessage(level LevelType, err error, fields map[string]interface{}, message string, callerPC uintptr) messageType {
+	var cerr *ErrorType
+	if err != nil {
+		if this.errorHandler != nil {
+			code, errType, errMessage := this.errorHandler(err)
+			cerr = &ErrorType{
+				Code:    code,
+				Type:    errType,
+				Message: errMessage,
+			}
+			if this.causesDepth > 0 {
+				cerr.Causes = collectCauses(err, this.errorHandler, this.causesDepth)
+			}
+		} else {
+			println("Warning: file logger found case errorHandler == nil")
+			cerr = &ErrorType{
+				Code:    0,
+				Type:    "",
+				Message: err.Error(),
+			}
+		}
 	}
+	return messageType{
+		Time: timeType{
+			Time: time.Now(),
+		},
+		LogLevel:     level.String(),
+		Error:        cerr,
+		Fields:       fields,
+		Message:      message,
+		CallerPC:     callerPC,
+		ErrorHandler: this.errorHandler,
+	}
+}
+
+/*	Сообщение ошибки через обработчик ошибок - для ошибок в значениях полей адаптеров (slog, logr)  */
+func (this *LoggerType) errorMessage(err error) string {
+	if this.errorHandler == nil {
+		return err.Error()
+	}
+	_, _, errMessage := this.errorHandler(err)
+	return errMessage
+}
+
+/*	Дочерний логгер с привязанными полями. Он использует те же файлы и ту же горутину записи что и родитель,
+**	поэтому создание дочернего логгера дешевое (например на каждый запрос). Привязанные поля добавляются в каждую
+**	запись, при совпадении ключей побеждают поля переданные в вызове. Поля родителя наследуются.
+**	Сеттеры дочернего логгера не влияют на родителя, Stop у дочернего логгера ничего не делает  */
+func (this *LoggerType) With(fields map[string]interface{}) *LoggerType {
+	child := *this
+	child.isChild = true
+	child.boundFields = this.format.mergeFields(this.boundFields, this.limits.limitFieldList(sortFields(fields)))
+	return &child
 }
 
 func (this *LoggerType) Stop() {
+	if this.isChild == true {
+		return
+	}
 	close(this.defaultFile.GetWriteChan())
 	if this.importantFile != nil {
 		close(this.importantFile.GetWriteChan())
@@ -198,140 +636,68 @@ func (this *LoggerType) Stop() {
 	if this.queryFile != nil {
 		close(this.queryFile.GetWriteChan())
 	}
+	if this.consoleFile != nil {
+		close(this.consoleFile.GetWriteChan())
+	}
 }
 
+/*	Единственная горутина записи во все файлы. Каналы отсутствующих файлов остаются nil - чтение из nil канала
+**	блокируется навсегда, поэтому такие ветки select никогда не срабатывают  */
 func (this *LoggerType) writeLoopAsync(wg *sync.WaitGroup, fileWriteDurationSeconds uint) {
 	ticker := time.NewTicker(time.Second * time.Duration(fileWriteDurationSeconds))
-	if this.importantFile != nil && this.queryFile != nil {
-		for {
-			select {
-			case <-ticker.C:
-				this.defaultFile.writeFromBufIfNotEmpty()
-				if this.importantFile != nil {
-					this.importantFile.writeFromBufIfNotEmpty()
-				}
-				if this.queryFile != nil {
-					this.queryFile.writeFromBufIfNotEmpty()
-				}
-			case cpyBuf, inWork := <-this.defaultFile.GetWriteChan():
-				if inWork == false {
-					this.defaultFile.writeFromBufIfNotEmpty()
-					if this.importantFile != nil {
-						this.importantFile.writeFromBufIfNotEmpty()
-					}
-					if this.queryFile != nil {
-						this.queryFile.writeFromBufIfNotEmpty()
-					}
-					ticker.Stop()
-					if err := this.defaultFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					if err := this.importantFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					if err := this.queryFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					wg.Done()
-					return
-				}
-				if len(cpyBuf) > 0 {
-					this.defaultFile.write(convertBufToBite(cpyBuf))
-				}
-			case cpyBuf := <-this.importantFile.GetWriteChan():
-				if len(cpyBuf) > 0 {
-					this.importantFile.write(convertBufToBite(cpyBuf))
-				}
-			case cpyBuf := <-this.queryFile.GetWriteChan():
-				if len(cpyBuf) > 0 {
-					this.queryFile.write(convertBufToBite(cpyBuf))
-				}
+	var files = []*fileType{this.defaultFile}
+	var importantChan, queryChan, consoleChan chan []messageType
+	if this.importantFile != nil {
+		files = append(files, this.importantFile)
+		importantChan = this.importantFile.GetWriteChan()
+	}
+	if this.queryFile != nil {
+		files = append(files, this.queryFile)
+		queryChan = this.queryFile.GetWriteChan()
+	}
+	if this.consoleFile != nil {
+		files = append(files, this.consoleFile)
+		consoleChan = this.consoleFile.GetWriteChan()
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, file := range files {
+				file.writeFromBufIfNotEmpty()
 			}
-		}
-	} else if this.importantFile != nil {
-		for {
-			select {
-			case <-ticker.C:
-				this.defaultFile.writeFromBufIfNotEmpty()
-				if this.importantFile != nil {
-					this.importantFile.writeFromBufIfNotEmpty()
-				}
-			case cpyBuf, inWork := <-this.defaultFile.GetWriteChan():
-				if inWork == false {
-					this.defaultFile.writeFromBufIfNotEmpty()
-					if this.importantFile != nil {
-						this.importantFile.writeFromBufIfNotEmpty()
+		case cpyBuf, inWork := <-this.defaultFile.GetWriteChan():
+			if inWork == false {
+				ticker.Stop()
+				for _, file := range files {
+					/*	Дописываю то что еще осталось в каналах остальных файлов (Stop закрывает их все)  */
+					if file != this.defaultFile {
+						for cpyBuf := range file.GetWriteChan() {
+							file.write(file.convertBufToBite(cpyBuf))
+						}
 					}
-					ticker.Stop()
-					if err := this.defaultFile.Close(); err != nil {
+					file.writeFromBufIfNotEmpty()
+					if err := file.Close(); err != nil {
 						fmt.Fprintf(os.Stderr, "%s", err)
 					}
-					if err := this.importantFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					wg.Done()
-					return
-				}
-				if len(cpyBuf) > 0 {
-					this.defaultFile.write(convertBufToBite(cpyBuf))
-				}
-			case cpyBuf := <-this.importantFile.GetWriteChan():
-				if len(cpyBuf) > 0 {
-					this.importantFile.write(convertBufToBite(cpyBuf))
 				}
+				wg.Done()
+				return
 			}
-		}
-	} else if this.queryFile != nil {
-		for {
-			select {
-			case <-ticker.C:
-				this.defaultFile.writeFromBufIfNotEmpty()
-				if this.queryFile != nil {
-					this.queryFile.writeFromBufIfNotEmpty()
-				}
-			case cpyBuf, inWork := <-this.defaultFile.GetWriteChan():
-				if inWork == false {
-					this.defaultFile.writeFromBufIfNotEmpty()
-					if this.queryFile != nil {
-						this.queryFile.writeFromBufIfNotEmpty()
-					}
-					ticker.Stop()
-					if err := this.defaultFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					if err := this.queryFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					wg.Done()
-					return
-				}
-				if len(cpyBuf) > 0 {
-					this.defaultFile.write(convertBufToBite(cpyBuf))
-				}
-			case cpyBuf := <-this.queryFile.GetWriteChan():
-				if len(cpyBuf) > 0 {
-					this.queryFile.write(convertBufToBite(cpyBuf))
-				}
+			if len(cpyBuf) > 0 {
+				this.defaultFile.write(this.defaultFile.convertBufToBite(cpyBuf))
 			}
-		}
-	} else {
-		for {
-			select {
-			case <-ticker.C:
-				this.defaultFile.writeFromBufIfNotEmpty()
-			case cpyBuf, inWork := <-this.defaultFile.GetWriteChan():
-				if inWork == false {
-					this.defaultFile.writeFromBufIfNotEmpty()
-					ticker.Stop()
-					if err := this.defaultFile.Close(); err != nil {
-						fmt.Fprintf(os.Stderr, "%s", err)
-					}
-					wg.Done()
-					return
-				}
-				if len(cpyBuf) > 0 {
-					this.defaultFile.write(convertBufToBite(cpyBuf))
-				}
+		case cpyBuf := <-importantChan:
+			if len(cpyBuf) > 0 {
+				this.importantFile.write(this.importantFile.convertBufToBite(cpyBuf))
+			}
+		case cpyBuf := <-queryChan:
+			if len(cpyBuf) > 0 {
+				this.queryFile.write(this.queryFile.convertBufToBite(cpyBuf))
+			}
+		case cpyBuf := <-consoleChan:
+			if len(cpyBuf) > 0 {
+				this.consoleFile.write(this.consoleFile.convertBufToBite(cpyBuf))
 			}
 		}
 	}
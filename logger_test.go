@@ -3,6 +3,9 @@ package flogger
 import (
 	yaml "github.com/GlobchanskyDenis/yaml"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -148,3 +151,140 @@ func TestDifferentLogTypes(t *testing.T) {
 		t.Logf("%sSuccess%s", GREEN_BG, NO_COLOR)
 	}
 }
+
+/*	Конфиг для тестов которым не нужен test.yaml - логи пишутся во временную папку теста  */
+func newTestConfig(t *testing.T) *ConfigType {
+	loggerConf := GetConfig()
+	*loggerConf = ConfigType{
+		ServiceName:              "test",
+		LogFolder:                t.TempDir(),
+		Permissions:              "755",
+		MaxHoursToChangeLogFile:  24,
+		MaxBufSize:               50,
+		FileWriteDurationSeconds: 1,
+		WriteChanSize:            5,
+	}
+	return loggerConf
+}
+
+/*	Останавливает логгер и возвращает содержимое файла логгирования указанного типа (default / important / query)  */
+func stopAndReadLogFile(t *testing.T, logger *LoggerType, wg *sync.WaitGroup, fileTypeName string) string {
+	var fileName string
+	switch fileTypeName {
+	case "important":
+		fileName = logger.importantFile.fileName
+	case "query":
+		fileName = logger.queryFile.fileName
+	default:
+		fileName = logger.defaultFile.fileName
+	}
+	logger.Stop()
+	wg.Wait()
+
+	body, err := os.ReadFile(filepath.Join(GetConfig().LogFolder, fileName))
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	return string(body)
+}
+
+func TestWith(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.EnableDecision = true
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	var child IServiceLogger = logger.With(map[string]interface{}{"request_id": "r1", "worker_num": 1})
+	var grandChild IBusinessLogger = logger.With(map[string]interface{}{"request_id": "r1"}).With(map[string]interface{}{"user_id": 7})
+
+	child.Info(map[string]interface{}{"worker_num": 2, "a": true}, "call site wins")
+	grandChild.Decision(nil, "inherited")
+	child.Stop()
+	logger.Info(nil, "parent without fields")
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 3 {
+		t.Errorf("%sFail: expected 3 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	expected := []string{
+		`"level":"INFO","a":true,"request_id":"r1","worker_num":2,"message":"call site wins"}`,
+		`"level":"DECISION","request_id":"r1","user_id":7,"message":"inherited"}`,
+		`"level":"INFO","message":"parent without fields"}`,
+	}
+	for i := range expected {
+		if strings.Contains(lines[i], expected[i]) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected[i], lines[i], NO_COLOR)
+		}
+	}
+}
+
+/*	Считает сколько раз аргумент был отформатирован  */
+type testCountingStringerType struct {
+	calls *int
+}
+
+func (this testCountingStringerType) String() string {
+	*this.calls++
+	return "counted"
+}
+
+func TestLazy(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.EnableFileForImportant = true
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+
+	var formatCalls, buildCalls int
+	logger.Error(nil, errors.New("cant do"), "error %s", testCountingStringerType{calls: &formatCalls})
+	logger.ServiceDebug(nil, "disabled %s", testCountingStringerType{calls: &formatCalls})
+	if formatCalls != 1 {
+		t.Errorf("%sFail: message must be formatted once and only for enabled levels, calls %d%s", RED_BG, formatCalls, NO_COLOR)
+	}
+
+	build := func() (map[string]interface{}, string) {
+		buildCalls++
+		return map[string]interface{}{"payload": "expensive"}, "100% lazy"
+	}
+	logger.ServiceDebugLazy(build)
+	logger.WarningLazy(errors.New("slow"), build)
+	if buildCalls != 1 {
+		t.Errorf("%sFail: build must be called only for enabled levels, calls %d%s", RED_BG, buildCalls, NO_COLOR)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 2 {
+		t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	expected := []string{
+		`"level":"ERROR","error":{"message":"cant do"},"message":"error counted"}`,
+		`"level":"WARNING","error":{"message":"slow"},"payload":"expensive","message":"100% lazy"}`,
+	}
+	for i := range expected {
+		if strings.Contains(lines[i], expected[i]) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected[i], lines[i], NO_COLOR)
+		}
+	}
+}
+
+func TestRotationConfig(t *testing.T) {
+	if _, err := newRotationInterval(&ConfigType{}); err == nil {
+		t.Errorf("%sFail: expected error for zero MaxHoursToChangeLogFile%s", RED_BG, NO_COLOR)
+	}
+	if _, err := newRotationInterval(&ConfigType{RotationInterval: "1h"}); err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+	}
+}
@@ -0,0 +1,103 @@
+package flogger
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+/*	Ключ поля с именем логгера logr (WithName)  */
+const logrNameKey = "logger"
+
+/*	Реализация logr.LogSink поверх логгера (для библиотек kubernetes и client-go). V(0) пишется как Info,
+**	V(1) и выше - как ServiceDebug. Error пишется уровнем Error с ошибкой через errorHandler логгера.
+**	Значения WithValues и имя WithName (через точку) становятся привязанными полями как у With.
+**	Сообщение пишется как есть (не форматная строка)  */
+type LogrSinkType struct {
+	logger *LoggerType // дочерний логгер со своим callerSkip и привязанными полями
+	name   string
+}
+
+var _ logr.LogSink = (*LogrSinkType)(nil)
+var _ logr.CallDepthLogSink = (*LogrSinkType)(nil)
+
+func NewLogrSink(logger *LoggerType) *LogrSinkType {
+	return &LogrSinkType{
+		logger: logger.With(nil),
+	}
+}
+
+/*	logr сообщает сколько своих фреймов он добавляет между пользовательским кодом и LogSink  */
+func (this *LogrSinkType) Init(info logr.RuntimeInfo) {
+	this.logger.callerSkip += info.CallDepth
+}
+
+func (this *LogrSinkType) Enabled(level int) bool {
+	return this.logger.isEnabled(logrLevel(level))
+}
+
+func (this *LogrSinkType) Info(level int, msg string, keysAndValues ...interface{}) {
+	entryLevel := logrLevel(level)
+	this.logger.log(entryType{level: entryLevel, targets: this.logger.levelTargets(entryLevel), fields: this.toFields(keysAndValues), message: msg})
+}
+
+func (this *LogrSinkType) Error(err error, msg string, keysAndValues ...interface{}) {
+	this.logger.log(entryType{level: levelError, targets: this.logger.levelTargets(levelError), err: err, fields: this.toFields(keysAndValues), message: msg})
+}
+
+func (this *LogrSinkType) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &LogrSinkType{
+		logger: this.logger.With(this.toFields(keysAndValues)),
+		name:   this.name,
+	}
+}
+
+func (this *LogrSinkType) WithName(name string) logr.LogSink {
+	if this.name != "" {
+		name = this.name + "." + name
+	}
+	return &LogrSinkType{
+		logger: this.logger.With(map[string]interface{}{logrNameKey: name}),
+		name:   name,
+	}
+}
+
+func (this *LogrSinkType) WithCallDepth(depth int) logr.LogSink {
+	child := &LogrSinkType{
+		logger: this.logger.With(nil),
+		name:   this.name,
+	}
+	child.logger.callerSkip += depth
+	return child
+}
+
+func logrLevel(level int) LevelType {
+	if level > 0 {
+		return levelServiceDebug
+	}
+	return levelInfo
+}
+
+/*	Пары ключ-значение в мапу полей. Ключ не строка - пишется через fmt.Sprint, ключ без значения - значение null.
+**	Ошибки в значениях пишутся сообщением errorHandler  */
+func (this *LogrSinkType) toFields(keysAndValues []interface{}) map[string]interface{} {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, isString := keysAndValues[i].(string)
+		if isString == false {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		if err, isError := value.(error); isError == true && err != nil {
+			value = this.logger.errorMessage(err)
+		}
+		fields[key] = value
+	}
+	return fields
+}
@@ -0,0 +1,66 @@
+package flogger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestLogrSink(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.CallerLevels = []string{"INFO"}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	logger.SetErrorHandler(func(err error) (uint, string, string) {
+		return 7, "Internal", "handled: " + err.Error()
+	})
+
+	log := logr.New(NewLogrSink(logger)).WithName("controller").WithValues("namespace", "default")
+	log.WithName("reconciler").Info("reconciled", "pod", "web-1", "attempt", 2)
+	log.V(1).Info("disabled level")
+	log.Error(errors.New("conflict"), "update failed", "cause", errors.New("stale"), 42)
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 2 {
+		t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	var testCases = []struct {
+		name     string
+		expected []string
+	}{
+		{
+			name: "info",
+			expected: []string{
+				`"level":"INFO","caller":"`,
+				`/logr_test.go:28"`,
+				`"attempt":2,"logger":"controller.reconciler","namespace":"default","pod":"web-1","message":"reconciled"}`,
+			},
+		},
+		{
+			name: "error",
+			expected: []string{
+				`"level":"ERROR","error":{"code":7,"type":"Internal","message":"handled: conflict"}`,
+				`"42":null,"cause":"handled: stale","logger":"controller","namespace":"default","message":"update failed"}`,
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, expected := range tc.expected {
+				if strings.Contains(lines[i], expected) == false {
+					t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[i], NO_COLOR)
+				}
+			}
+		})
+	}
+}
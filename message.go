@@ -1,175 +1,175 @@
 package flogger
 
 import (
-	"encoding/json"
-	"fmt"
-	"strconv"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 )
 
 type messageType struct {
-	Timestamp int64      `json:"stamp"`           // Это поле для обработки программой автоматического чтения логов (чтобы не приходилось парсить время)
-	Time      timeType   `json:"time"`            // Это человекочитаемое время (без даты, дата в любом случае отображается в названии файла логгирования)
-	LogLevel  string     `json:"level"`           // Error / Info / Debug / Warning...
-	Error     *errorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
-	Fields    map[string]interface{}
-	Message   string `json:"message"`
+	Time          timeType   `json:"time"`            // Из этого поля энкодер формирует и stamp и человекочитаемое время
+	LogLevel      string     `json:"level"`           // Error / Info / Debug / Warning...
+	Error         *ErrorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
+	Fields        map[string]interface{}
+	FieldList     []FieldType                        // типизированные поля (методы InfoF, ErrorF...)
+	BoundFields   []FieldType                        // поля дочернего логгера (With), уступают полям контекста и вызова
+	ContextFields []FieldType                        // поля извлеченные из контекста (методы InfoCtx...), уступают полям вызова
+	Message       string                             `json:"message"`
+	CallerPC      uintptr                            // адрес места вызова, 0 если caller для уровня выключен
+	StackPCs      []uintptr                          // адреса вызовов стека, nil если стек для уровня выключен
+	ErrorHandler  func(error) (uint, string, string) // обработчик ошибок логгера - для полей Err, nil - error.Error()
 }
 
-/*	Сериализуем сообщение компактнее чем позволяет стандартный маршаллер
-**	(убираем лишние пробелы между полями (как и в jsonb), параметр Args без рефлексии
-**	превращается в поля текущей структуры и сортируется по алфавиту)
-**	Соблюдается совместимость с форматом json (но из-за особенностей сериализации параметра Args
-**	данная dto недействительна при Unmarshal (нужно будет анмаршаллить в мапу))  */
+/*	Сериализация энкодером по умолчанию (компактный json) в формате по умолчанию  */
 func (this messageType) MarshalJSON() ([]byte, error) {
+	record := this.toRecord(gDefaultFormat, gDefaultLimits)
+	return jsonEncoderType{}.Encode(make([]byte, 0, 256), &record), nil
+}
 
-	timepart := this.Time.marshalString()
-	var errPart string
-	if this.Error != nil {
-		jsonB, _ := json.Marshal(this.Error.Message)
-		if this.Error.Code != 0 && this.Error.Type != "" {
-			errPart = "{\"code\":" + strconv.FormatUint(uint64(this.Error.Code), 10) + ",\"type\":\"" + this.Error.Type + "\",\"message\":" + string(jsonB) + "}"
-		} else {
-			errPart = "{\"message\":" + string(jsonB) + "}"
+/*	Формат и ограничения - логгера которому принадлежит запись  */
+func (this messageType) toRecord(format *formatType, limits *limitsType) RecordType {
+	record := RecordType{
+		Time:    this.Time.Time,
+		Level:   this.LogLevel,
+		Error:   this.Error,
+		Static:  format.static,
+		Fields:  limits.limitFieldsCount(format.static.dropStaticKeys(format.mergeFields(format.mergeFields(this.BoundFields, this.ContextFields), format.mergeFields(sortFields(this.Fields), this.FieldList)))),
+		Message: this.Message,
+		format:  format,
+	}
+	record.Fields = this.resolveErrorFields(record.Fields, limits)
+	record.Stack = resolveStack(this.StackPCs)
+	if this.CallerPC != 0 {
+		caller, function := resolveCaller(this.CallerPC)
+		record.Caller = caller
+		if format.callerWithFunc == true {
+			record.Function = function
 		}
 	}
+	return record
+}
 
-	var dst []byte
-	dst = append(dst, []byte("{\"stamp\":"+strconv.FormatUint(uint64(this.Timestamp), 10)+
-		",\"time\":"+timepart+",\"level\":\""+this.LogLevel+"\",")...)
-	if this.Error != nil {
-		dst = append(dst, []byte("\"error\":"+errPart+",")...)
+/*	Поля из мапы в порядке сортировки ключей по алфавиту (побайтово)  */
+func sortFields(fieldsMap map[string]interface{}) []FieldType {
+	if len(fieldsMap) == 0 {
+		return nil
 	}
 
-	dst = append(dst, convertFields(this.Fields)...)
-	dst = append(dst, []byte("\"message\":\""+this.Message+"\"}\n")...)
-	return dst, nil
+	keyList := getSortedKeys(fieldsMap)
+	var fields = make([]FieldType, len(*keyList))
+	for i, key := range *keyList {
+		fields[i] = FieldType{Key: key, Value: fieldsMap[key]}
+	}
+	putKeyList(keyList)
+	return fields
 }
 
-func convertFields(fieldsMap map[string]interface{}) []byte {
-	if len(fieldsMap) == 0 {
-		return nil
+/*	Слайсы под ключи переиспользуются - сортировка ключей на каждом уровне вложенности не должна аллоцировать  */
+var gKeyListPool = &sync.Pool{
+	New: func() interface{} {
+		keyList := make([]string, 0, 16)
+		return &keyList
+	},
+}
+
+/*	Возвращает отсортированные ключи мапы в слайсе из пула. Слайс нужно вернуть в пул через putKeyList  */
+func getSortedKeys(fieldsMap map[string]interface{}) *[]string {
+	keyList := gKeyListPool.Get().(*[]string)
+	for key := range fieldsMap {
+		*keyList = append(*keyList, key)
 	}
+	sort.Strings(*keyList)
+	return keyList
+}
 
-	/*	Заполняем слайс ключами  */
-	var keyList = make([]string, len(fieldsMap))
-	var i = 0
-	for key, _ := range fieldsMap {
-		keyList[i] = key
-		i++
+func putKeyList(keyList *[]string) {
+	for i := range *keyList {
+		(*keyList)[i] = "" // чтобы пул не удерживал строки ключей
 	}
+	*keyList = (*keyList)[:0]
+	gKeyListPool.Put(keyList)
+}
 
-	/*	Сортируем ключи в слайсе по алфавиту улучшенным баблсортом (количество аргументов все равно небольшое)  */
-	for {
-		var wasSwapped bool = false
-		for i := 0; i < len(keyList)-1; i++ {
-			if isNeedToSwap(keyList[i], keyList[i+1]) {
-				var temp = keyList[i]
-				keyList[i] = keyList[i+1]
-				keyList[i+1] = temp
-				wasSwapped = true
-			}
+/*	Текст ошибок полей Err получается через обработчик ошибок только здесь - в горутине записи и только для
+**	записанных уровней. Слайс может принадлежать вызывающему коду - при изменениях создается копия  */
+func (this messageType) resolveErrorFields(fields []FieldType, limits *limitsType) []FieldType {
+	var result []FieldType
+	for i := range fields {
+		if fields[i].kind != fieldKindError {
+			continue
+		}
+		if result == nil {
+			result = append([]FieldType(nil), fields...)
 		}
-		if wasSwapped == false {
-			break
+		err := fields[i].Value.(error)
+		text := safeString(err.Error)
+		if this.ErrorHandler != nil {
+			text = safeString(func() string {
+				_, _, errMessage := this.ErrorHandler(err)
+				return errMessage
+			})
 		}
+		result[i], _ = limits.limitField(String(fields[i].Key, text), 1)
 	}
-
-	/*	Конвертируем содержимое мапы в порядке который мы получили после сортировки ключей  */
-	var dst []byte
-	for _, key := range keyList {
-		dst = append(dst, []byte("\""+key+"\":")...)
-		dst = append(dst, []byte(stringify(fieldsMap[key]))...)
-		dst = append(dst, byte(','))
+	if result == nil {
+		return fields
 	}
-	return dst
+	return result
 }
 
-/*	Сравнивает два ключа по алфавиту и говорит нужно ли свапнуть ключи при заполнении из мапы  */
-func isNeedToSwap(key1, key2 string) bool {
-	/*	Защита от дурака  */
-	if len(key1) == 0 {
-		return false
-	}
-	if len(key2) == 0 {
-		return true
+/*	Упорядоченный список полей сортируется по ключу если не включен параметр KeepFieldsOrder.
+**	Исходный слайс принадлежит пользователю, поэтому сортируется копия  */
+func (this *formatType) orderFields(fields []FieldType) []FieldType {
+	if this.keepFieldsOrder == true || isFieldsSorted(fields) == true {
+		return fields
 	}
+	sorted := append(make([]FieldType, 0, len(fields)), fields...)
+	slices.SortStableFunc(sorted, compareFieldKeys)
+	return sorted
+}
 
-	var minLen = len(key1)
-	if minLen > len(key2) {
-		minLen = len(key2)
+/*	Объединяет отсортированные поля с упорядоченным списком. При совпадении ключей побеждает поле из списка  */
+func (this *formatType) mergeFields(base []FieldType, list []FieldType) []FieldType {
+	if len(list) == 0 {
+		return base
 	}
-	for i := 0; i < minLen; i++ {
-		if key1[i] > key2[i] {
-			return true
-		} else if key1[i] < key2[i] {
-			return false
+	list = this.orderFields(list)
+	if len(base) == 0 {
+		return list
+	}
+	merged := make([]FieldType, 0, len(base)+len(list))
+	for _, field := range base {
+		if containsFieldKey(list, field.Key) == false {
+			merged = append(merged, field)
 		}
 	}
-	if len(key1) > len(key2) {
-		return true
+	merged = append(merged, list...)
+	if this.keepFieldsOrder == false {
+		slices.SortStableFunc(merged, compareFieldKeys)
 	}
-	return false
+	return merged
 }
 
-func stringify(src interface{}) string {
-	switch typed := src.(type) {
-	case int:
-		return strconv.Itoa(typed)
-	case int64:
-		return strconv.FormatInt(typed, 10)
-	case int32:
-		return strconv.FormatInt(int64(typed), 10)
-	case uint:
-		return strconv.FormatUint(uint64(typed), 10)
-	case uint32:
-		return strconv.FormatUint(uint64(typed), 10)
-	case uint64:
-		return strconv.FormatUint(typed, 10)
-	case float64:
-		return strconv.FormatFloat(typed, 'E', -1, 64)
-	case float32:
-		return strconv.FormatFloat(float64(typed), 'E', -1, 32)
-	case bool:
-		return strconv.FormatBool(typed)
-	case map[string]interface{}:
-		if typed == nil {
-			return "\"map[string]interface{} nil\""
-		}
-		var dst string = "{"
-		var i int = 0
-		for key, value := range typed {
-			i++
-			dst += "\"" + key + "\":" + stringify(value)
-			if i != len(typed) {
-				dst += ","
-			}
-		}
-		return dst + "}"
-	case []interface{}:
-		var dst string = "["
-		for i, value := range typed {
-			dst += stringify(value)
-			if i < len(typed)-1 {
-				dst += ","
-			}
+func containsFieldKey(fields []FieldType, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
 		}
-		return dst + "]"
-	case string:
-		return "\"" + typed + "\""
-	default:
-		return fmt.Sprintf("\"%T\"", src)
 	}
+	return false
 }
 
-func convertBufToBite(cpyBuf []messageType) []byte {
-	var dst []byte
-	for _, message := range cpyBuf {
-		jsonB, err := message.MarshalJSON()
-		if err != nil {
-			println("не смог сериализовать лог " + err.Error())
-		} else {
-			dst = append(dst, jsonB...)
+/*	Без sort.Interface - упаковка слайса в интерфейс аллоцирует на каждой записи  */
+func isFieldsSorted(fields []FieldType) bool {
+	for i := 1; i < len(fields); i++ {
+		if fields[i].Key < fields[i-1].Key {
+			return false
 		}
 	}
-	return dst
+	return true
+}
+
+func compareFieldKeys(a, b FieldType) int {
+	return strings.Compare(a.Key, b.Key)
 }
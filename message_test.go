@@ -1,7 +1,9 @@
 package flogger
 
 import (
+	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,10 +17,9 @@ import (
 /*	go test -bench . -benchmem  */
 func BenchmarkMessageMarshalCustom(b *testing.B) {
 	var dto = messageType{
-		Timestamp: 100500,
-		Time:      timeType{Time: time.Now()},
-		LogLevel:  "Warning",
-		Error: &errorType{
+		Time:     timeType{Time: time.Unix(100500, 0)},
+		LogLevel: "Warning",
+		Error: &ErrorType{
 			Code:    42,
 			Type:    "Business",
 			Message: "cant do something: \"becouse of...,\"",
@@ -45,7 +46,7 @@ func BenchmarkMessageMarshalStandart(b *testing.B) {
 		Timestamp uint       `json:"stamp"`           // Это поле для обработки программой автоматического чтения логов (чтобы не приходилось парсить время)
 		Time      timeType   `json:"time"`            // Это человекочитаемое время (без даты, дата в любом случае отображается в названии файла логгирования)
 		LogLevel  string     `json:"level"`           // Error / Info / Debug / Warning...
-		Error     *errorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
+		Error     *ErrorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
 		Fields    map[string]interface{}
 		Message   string `json:"message"`
 	}
@@ -53,7 +54,7 @@ func BenchmarkMessageMarshalStandart(b *testing.B) {
 		Timestamp: 100500,
 		Time:      timeType{Time: time.Now()},
 		LogLevel:  "Warning",
-		Error: &errorType{
+		Error: &ErrorType{
 			Code:    42,
 			Type:    "Business",
 			Message: "cant do something: \"becouse of...,\"",
@@ -77,10 +78,9 @@ func BenchmarkMessageMarshalStandart(b *testing.B) {
 func TestMessageMarshalLength(t *testing.T) {
 	t.Run("custom message", func(t *testing.T) {
 		var dto = messageType{
-			Timestamp: 100500,
-			Time:      timeType{Time: time.Now()},
-			LogLevel:  "Warning",
-			Error: &errorType{
+			Time:     timeType{Time: time.Unix(100500, 0)},
+			LogLevel: "Warning",
+			Error: &ErrorType{
 				Code:    42,
 				Type:    "Business",
 				Message: "cant do something: \"becouse of...,\"",
@@ -118,7 +118,7 @@ func TestMessageMarshalLength(t *testing.T) {
 			Timestamp uint       `json:"stamp"`           // Это поле для обработки программой автоматического чтения логов (чтобы не приходилось парсить время)
 			Time      timeType   `json:"time"`            // Это человекочитаемое время (без даты, дата в любом случае отображается в названии файла логгирования)
 			LogLevel  string     `json:"level"`           // Error / Info / Debug / Warning...
-			Error     *errorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
+			Error     *ErrorType `json:"error,omitempty"` // Код, тип (Internal / External / Request / Business) и тело ошибки
 			Fields    map[string]interface{}
 			Message   string `json:"message"`
 		}
@@ -126,7 +126,7 @@ func TestMessageMarshalLength(t *testing.T) {
 			Timestamp: 100500,
 			Time:      timeType{Time: time.Now()},
 			LogLevel:  "Warning",
-			Error: &errorType{
+			Error: &ErrorType{
 				Code:    42,
 				Type:    "Business",
 				Message: "cant do something: \"becouse of...,\"",
@@ -165,3 +165,105 @@ func TestMessageMarshalLength(t *testing.T) {
 		}
 	})
 }
+
+func TestMessageMarshalEscaping(t *testing.T) {
+	testCases := []struct {
+		name    string
+		message string
+		field   interface{}
+	}{
+		{name: "quote", message: `say "hi"`, field: `"quoted"`},
+		{name: "backslash", message: `C:\path\`, field: `\`},
+		{name: "newline injection", message: "line\n{\"stamp\":1,\"level\":\"FATAL\"}", field: "a\r\nb"},
+		{name: "control characters", message: "\x00\x01\x1f\x7f\t", field: "\b\f"},
+		{name: "invalid utf-8", message: "bad \xff\xfe byte", field: "\xc3\x28"},
+		{name: "line separators", message: "a\u2028b\u2029c", field: "юникод 🙂"},
+		{name: "nested", message: "nested", field: map[string]interface{}{"k\"ey": []interface{}{"v\nal", 1}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dto = messageType{
+				Time:     timeType{Time: time.Now()},
+				LogLevel: "INFO",
+				Error: &ErrorType{
+					Code:    42,
+					Type:    "Busi\"ness",
+					Message: tc.message,
+				},
+				Fields: map[string]interface{}{
+					"fi\"eld\n": tc.field,
+				},
+				Message: tc.message,
+			}
+			jsonB, err := dto.MarshalJSON()
+			if err != nil {
+				t.Errorf("Error: %s", err)
+				t.FailNow()
+			}
+			if bytes.Count(jsonB, []byte("\n")) != 1 || jsonB[len(jsonB)-1] != '\n' {
+				t.Errorf("Fail: record is not a single line %q", jsonB)
+			}
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(jsonB, &decoded); err != nil {
+				t.Errorf("Fail: invalid json %q %s", jsonB, err)
+				t.FailNow()
+			}
+			if expected := strings.Replace(tc.message, "\xff\xfe", "\ufffd\ufffd", 1); decoded["message"] != expected {
+				t.Errorf("Fail: expected message %q got %q", expected, decoded["message"])
+			}
+		})
+	}
+}
+
+/*	go test -fuzz FuzzAppendJSONString  */
+func FuzzAppendJSONString(f *testing.F) {
+	for _, seed := range []string{"", "plain", `"`, `\`, "\n\r\t", "\x00\x1f", "\xff", "\u2028", "<&>", "юникод"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		jsonB := appendJSONString(nil, src)
+		if json.Valid(jsonB) == false {
+			t.Fatalf("Fail: invalid json %q for %q", jsonB, src)
+		}
+		var custom, standart string
+		if err := json.Unmarshal(jsonB, &custom); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		etalonB, err := json.Marshal(src)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if err := json.Unmarshal(etalonB, &standart); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if custom != standart {
+			t.Fatalf("Fail: expected %q got %q", standart, custom)
+		}
+	})
+}
+
+/*	go test -bench . -benchmem  */
+func BenchmarkAppendJSONString(b *testing.B) {
+	var dst = make([]byte, 0, 256)
+	for i := 0; i < b.N; i++ {
+		dst = appendJSONString(dst[:0], "cant do something: \"becouse of...,\" юникод\n")
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	fields := sortFields(map[string]interface{}{
+		"worker":  1,
+		"arg1asd": "asddsadasd",
+		"":        "empty",
+		"arg1":    "asds",
+		"Arg2":    "fdsjkfhdsfjkh",
+	})
+	var keyList []string
+	for _, field := range fields {
+		keyList = append(keyList, field.Key)
+	}
+	if result := strings.Join(keyList, ","); result != ",Arg2,arg1,arg1asd,worker" {
+		t.Errorf("Fail: unexpected order %s", result)
+	}
+}
@@ -0,0 +1,467 @@
+package flogger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+/*	Примитивы формата MessagePack (https://github.com/msgpack/msgpack/blob/master/spec.md).
+**	Целые числа пишутся в минимально возможном представлении  */
+
+func appendMsgpackNil(dst []byte) []byte {
+	return append(dst, 0xc0)
+}
+
+func appendMsgpackBool(dst []byte, value bool) []byte {
+	if value == true {
+		return append(dst, 0xc3)
+	}
+	return append(dst, 0xc2)
+}
+
+func appendMsgpackInt(dst []byte, value int64) []byte {
+	switch {
+	case value >= 0:
+		return appendMsgpackUint(dst, uint64(value))
+	case value >= -32:
+		return append(dst, byte(value))
+	case value >= math.MinInt8:
+		return append(dst, 0xd0, byte(value))
+	case value >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(value))
+	case value >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(value))
+	}
+}
+
+func appendMsgpackUint(dst []byte, value uint64) []byte {
+	switch {
+	case value <= 0x7f:
+		return append(dst, byte(value))
+	case value <= math.MaxUint8:
+		return append(dst, 0xcc, byte(value))
+	case value <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(value))
+	case value <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xce), uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xcf), value)
+	}
+}
+
+func appendMsgpackFloat(dst []byte, value float64, bitSize int) []byte {
+	if bitSize == 32 {
+		return binary.BigEndian.AppendUint32(append(dst, 0xca), math.Float32bits(float32(value)))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, 0xcb), math.Float64bits(value))
+}
+
+func appendMsgpackString(dst []byte, value string) []byte {
+	switch length := len(value); {
+	case length < 32:
+		dst = append(dst, 0xa0|byte(length))
+	case length <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(length))
+	case length <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xda), uint16(length))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xdb), uint32(length))
+	}
+	return append(dst, value...)
+}
+
+func appendMsgpackBinary(dst []byte, value []byte) []byte {
+	switch length := len(value); {
+	case length <= math.MaxUint8:
+		dst = append(dst, 0xc4, byte(length))
+	case length <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xc5), uint16(length))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xc6), uint32(length))
+	}
+	return append(dst, value...)
+}
+
+func appendMsgpackArrayHeader(dst []byte, length int) []byte {
+	switch {
+	case length < 16:
+		return append(dst, 0x90|byte(length))
+	case length <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xdc), uint16(length))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, 0xdd), uint32(length))
+	}
+}
+
+func appendMsgpackMapHeader(dst []byte, length int) []byte {
+	switch {
+	case length < 16:
+		return append(dst, 0x80|byte(length))
+	case length <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xde), uint16(length))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, 0xdf), uint32(length))
+	}
+}
+
+/*	Значение поля записи. Типизированные поля пишутся без упаковки в interface{}  */
+func appendMsgpackFieldValue(dst []byte, field *FieldType, path *valuePathType) []byte {
+	switch field.kind {
+	case fieldKindString:
+		return appendMsgpackString(dst, field.text)
+	case fieldKindInt64:
+		return appendMsgpackInt(dst, field.integer)
+	case fieldKindBool:
+		return appendMsgpackBool(dst, field.integer != 0)
+	case fieldKindDuration:
+		return appendMsgpackString(dst, time.Duration(field.integer).String())
+	case fieldKindTime:
+		return appendMsgpackString(dst, field.timeValue().Format(time.RFC3339Nano))
+	default:
+		return appendMsgpackValue(dst, field.Value, path)
+	}
+}
+
+/*	Значение поля. Часто используемые типы кодируются напрямую, остальные (структуры, мапы с нестроковыми ключами,
+**	типы с MarshalJSON...) - через json представление, поэтому логическая схема совпадает с json энкодером  */
+func appendMsgpackValue(dst []byte, src interface{}, path *valuePathType) []byte {
+	switch typed := src.(type) {
+	case nil:
+		return appendMsgpackNil(dst)
+	case string:
+		return appendMsgpackString(dst, typed)
+	case int:
+		return appendMsgpackInt(dst, int64(typed))
+	case int64:
+		return appendMsgpackInt(dst, typed)
+	case int32:
+		return appendMsgpackInt(dst, int64(typed))
+	case int16:
+		return appendMsgpackInt(dst, int64(typed))
+	case int8:
+		return appendMsgpackInt(dst, int64(typed))
+	case uint:
+		return appendMsgpackUint(dst, uint64(typed))
+	case uint64:
+		return appendMsgpackUint(dst, typed)
+	case uint32:
+		return appendMsgpackUint(dst, uint64(typed))
+	case uint16:
+		return appendMsgpackUint(dst, uint64(typed))
+	case uint8:
+		return appendMsgpackUint(dst, uint64(typed))
+	case float64:
+		return appendMsgpackFloat(dst, typed, 64)
+	case float32:
+		return appendMsgpackFloat(dst, float64(typed), 32)
+	case bool:
+		return appendMsgpackBool(dst, typed)
+	case time.Time:
+		return appendMsgpackString(dst, typed.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendMsgpackString(dst, typed.String())
+	case []byte:
+		if typed == nil {
+			return appendMsgpackNil(dst)
+		}
+		return appendMsgpackBinary(dst, typed)
+	case []string:
+		if typed == nil {
+			return appendMsgpackNil(dst)
+		}
+		dst = appendMsgpackArrayHeader(dst, len(typed))
+		for _, value := range typed {
+			dst = appendMsgpackString(dst, value)
+		}
+		return dst
+	case map[string]interface{}:
+		if typed == nil {
+			return appendMsgpackNil(dst)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendMsgpackString(dst, marker)
+		}
+		keyList := getSortedKeys(typed)
+		dst = appendMsgpackMapHeader(dst, len(*keyList))
+		for _, key := range *keyList {
+			dst = appendMsgpackString(dst, key)
+			dst = appendMsgpackValue(dst, typed[key], path)
+		}
+		putKeyList(keyList)
+		path.leave()
+		return dst
+	case []FieldType:
+		if typed == nil {
+			return appendMsgpackNil(dst)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendMsgpackString(dst, marker)
+		}
+		fields := path.format.orderFields(typed)
+		dst = appendMsgpackMapHeader(dst, len(fields))
+		for i := range fields {
+			dst = appendMsgpackString(dst, fields[i].Key)
+			dst = appendMsgpackFieldValue(dst, &fields[i], path)
+		}
+		path.leave()
+		return dst
+	case []interface{}:
+		if typed == nil {
+			return appendMsgpackNil(dst)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendMsgpackString(dst, marker)
+		}
+		dst = appendMsgpackArrayHeader(dst, len(typed))
+		for _, value := range typed {
+			dst = appendMsgpackValue(dst, value, path)
+		}
+		path.leave()
+		return dst
+	}
+	jsonValue := appendReflectValue(nil, reflect.ValueOf(src), path)
+	result, err := appendMsgpackFromJSON(dst, json.NewDecoder(bytes.NewReader(jsonValue)))
+	if err != nil {
+		return appendMsgpackString(dst, string(jsonValue))
+	}
+	return result
+}
+
+/*	Перекодирует json значение с сохранением порядка ключей объектов  */
+func appendMsgpackFromJSON(dst []byte, decoder *json.Decoder) ([]byte, error) {
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return dst, err
+	}
+	switch typed := token.(type) {
+	case nil:
+		return appendMsgpackNil(dst), nil
+	case bool:
+		return appendMsgpackBool(dst, typed), nil
+	case string:
+		return appendMsgpackString(dst, typed), nil
+	case json.Number:
+		if value, err := strconv.ParseInt(string(typed), 10, 64); err == nil {
+			return appendMsgpackInt(dst, value), nil
+		}
+		if value, err := strconv.ParseUint(string(typed), 10, 64); err == nil {
+			return appendMsgpackUint(dst, value), nil
+		}
+		value, err := typed.Float64()
+		if err != nil {
+			return dst, err
+		}
+		return appendMsgpackFloat(dst, value, 64), nil
+	case json.Delim:
+		var body []byte
+		var count int
+		for decoder.More() == true {
+			if typed == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return dst, err
+				}
+				body = appendMsgpackString(body, key.(string))
+			}
+			if body, err = appendMsgpackFromJSON(body, decoder); err != nil {
+				return dst, err
+			}
+			count++
+		}
+		/*	Закрывающая скобка  */
+		if _, err := decoder.Token(); err != nil {
+			return dst, err
+		}
+		if typed == '{' {
+			dst = appendMsgpackMapHeader(dst, count)
+		} else {
+			dst = appendMsgpackArrayHeader(dst, count)
+		}
+		return append(dst, body...), nil
+	}
+	return dst, fmt.Errorf("Неожиданный json токен %v", token)
+}
+
+/*	Перекодирует файл энкодера msgpack (записи с префиксом длины) в json - по одной записи на строку.
+**	Числа с плавающей точкой выводятся по правилам параметров FloatFormat и FloatNonFinite конфига пакета  */
+func MsgpackToJSON(dst io.Writer, src io.Reader) error {
+	format, err := newFormat(GetConfig())
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(src)
+	writer := bufio.NewWriter(dst)
+	var header [msgpackLengthSize]byte
+	var record, line []byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if err == io.EOF {
+				return writer.Flush()
+			}
+			return fmt.Errorf("Не смог прочитать длину записи %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length > msgpackMaxRecord {
+			return fmt.Errorf("Длина записи msgpack %d больше допустимой %d - файл поврежден", length, msgpackMaxRecord)
+		}
+		if cap(record) < int(length) {
+			record = make([]byte, length)
+		}
+		record = record[:length]
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return fmt.Errorf("Не смог прочитать запись %w", err)
+		}
+		rest, result, err := appendJSONFromMsgpack(line[:0], record, format)
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return errors.New("Запись msgpack содержит лишние данные")
+		}
+		line = append(result, '\n')
+		if _, err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+}
+
+var errMsgpackShort = errors.New("Запись msgpack обрезана")
+
+/*	Декодирует одно значение из src в json. Возвращает остаток src  */
+func appendJSONFromMsgpack(dst []byte, src []byte, format *formatType) ([]byte, []byte, error) {
+	if len(src) == 0 {
+		return src, dst, errMsgpackShort
+	}
+	head := src[0]
+	src = src[1:]
+	switch {
+	case head <= 0x7f:
+		return src, strconv.AppendUint(dst, uint64(head), 10), nil
+	case head >= 0xe0:
+		return src, strconv.AppendInt(dst, int64(int8(head)), 10), nil
+	case head&0xf0 == 0x80:
+		return appendJSONObjectFromMsgpack(dst, src, int(head&0x0f), format)
+	case head&0xf0 == 0x90:
+		return appendJSONArrayFromMsgpack(dst, src, int(head&0x0f), format)
+	case head&0xe0 == 0xa0:
+		return appendJSONStringFromMsgpack(dst, src, int(head&0x1f))
+	}
+
+	/*	Дальше у всех типов после первого байта идет аргумент фиксированной длины  */
+	size, isKnown := msgpackArgumentSizes[head]
+	if isKnown == false {
+		return src, dst, fmt.Errorf("Тип msgpack 0x%x не поддерживается", head)
+	}
+	if len(src) < size {
+		return src, dst, errMsgpackShort
+	}
+	var argument uint64
+	for _, b := range src[:size] {
+		argument = argument<<8 | uint64(b)
+	}
+	src = src[size:]
+
+	switch head {
+	case 0xc0:
+		return src, append(dst, "null"...), nil
+	case 0xc2:
+		return src, append(dst, "false"...), nil
+	case 0xc3:
+		return src, append(dst, "true"...), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return src, strconv.AppendUint(dst, argument, 10), nil
+	case 0xd0:
+		return src, strconv.AppendInt(dst, int64(int8(argument)), 10), nil
+	case 0xd1:
+		return src, strconv.AppendInt(dst, int64(int16(argument)), 10), nil
+	case 0xd2:
+		return src, strconv.AppendInt(dst, int64(int32(argument)), 10), nil
+	case 0xd3:
+		return src, strconv.AppendInt(dst, int64(argument), 10), nil
+	case 0xca:
+		return src, format.appendFloat(dst, float64(math.Float32frombits(uint32(argument))), 32), nil
+	case 0xcb:
+		return src, format.appendFloat(dst, math.Float64frombits(argument), 64), nil
+	case 0xd9, 0xda, 0xdb:
+		return appendJSONStringFromMsgpack(dst, src, int(argument))
+	case 0xc4, 0xc5, 0xc6:
+		if uint64(len(src)) < argument {
+			return src, dst, errMsgpackShort
+		}
+		return src[argument:], appendBase64(dst, src[:argument]), nil
+	case 0xdc, 0xdd:
+		return appendJSONArrayFromMsgpack(dst, src, int(argument), format)
+	default:
+		return appendJSONObjectFromMsgpack(dst, src, int(argument), format)
+	}
+}
+
+/*	Размер аргумента (длины либо значения) после первого байта  */
+var msgpackArgumentSizes = map[byte]int{
+	0xc0: 0, 0xc2: 0, 0xc3: 0,
+	0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8,
+	0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8,
+	0xca: 4, 0xcb: 8,
+	0xd9: 1, 0xda: 2, 0xdb: 4,
+	0xc4: 1, 0xc5: 2, 0xc6: 4,
+	0xdc: 2, 0xdd: 4,
+	0xde: 2, 0xdf: 4,
+}
+
+func appendJSONStringFromMsgpack(dst []byte, src []byte, length int) ([]byte, []byte, error) {
+	if len(src) < length {
+		return src, dst, errMsgpackShort
+	}
+	return src[length:], appendJSONString(dst, string(src[:length])), nil
+}
+
+func appendJSONArrayFromMsgpack(dst []byte, src []byte, length int, format *formatType) ([]byte, []byte, error) {
+	var err error
+	dst = append(dst, '[')
+	for i := 0; i < length; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if src, dst, err = appendJSONFromMsgpack(dst, src, format); err != nil {
+			return src, dst, err
+		}
+	}
+	return src, append(dst, ']'), nil
+}
+
+/*	Ключи объектов всегда строки - так пишет энкодер msgpack  */
+func appendJSONObjectFromMsgpack(dst []byte, src []byte, length int, format *formatType) ([]byte, []byte, error) {
+	var err error
+	dst = append(dst, '{')
+	for i := 0; i < length; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if len(src) == 0 {
+			return src, dst, errMsgpackShort
+		}
+		if src[0]&0xe0 != 0xa0 && src[0] != 0xd9 && src[0] != 0xda && src[0] != 0xdb {
+			return src, dst, fmt.Errorf("Ключ объекта msgpack должен быть строкой (тип 0x%x)", src[0])
+		}
+		if src, dst, err = appendJSONFromMsgpack(dst, src, format); err != nil {
+			return src, dst, err
+		}
+		dst = append(dst, ':')
+		if src, dst, err = appendJSONFromMsgpack(dst, src, format); err != nil {
+			return src, dst, err
+		}
+	}
+	return src, append(dst, '}'), nil
+}
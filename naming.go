@@ -0,0 +1,125 @@
+package flogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	namingNative = "native"
+	namingECS    = "ecs"
+	namingOTel   = "otel"
+)
+
+/*	Профиль имен полей json энкодера. Собственный формат модуля (native) - тоже профиль, поэтому json энкодер
+**	один на все профили. Остальные профили - для систем сбора логов которые ожидают стандартные имена
+**	(Elastic Common Schema, семантические соглашения OpenTelemetry)  */
+type namingType struct {
+	stampKey        string // число в единицах TimestampUnit, пустой - не пишется
+	timeKey         string
+	timeFormat      uint8 // см. timeFormat*
+	levelKey        string
+	severityKey     string         // пустой - номер уровня не пишется
+	severities      map[string]int // номер уровня по его имени, в том числе для QUERY / IMPORTANT / DECISION
+	errorKey        string         // ошибка вложенным объектом, пустой - плоскими ключами error*Key
+	errorCodeKey    string
+	errorTypeKey    string
+	errorMessageKey string
+	errorCausesKey  string
+	callerKey       string // место вызова одной строкой, пустой - раздельно fileKey и lineKey
+	fileKey         string
+	lineKey         string
+	functionKey     string
+	stackKey        string
+	stackAsFrames   bool // стек массивом кадров, иначе одной строкой
+	messageKey      string
+}
+
+const (
+	timeFormatLayout  uint8 = iota // строкой по параметру TimeLayout
+	timeFormatRFC3339              // строкой RFC 3339 с наносекундами
+	timeFormatNano                 // целым числом наносекунд
+)
+
+/*	Уровни syslog (RFC 5424): 2 - critical, 3 - error, 4 - warning, 5 - notice, 6 - informational, 7 - debug  */
+var gSyslogSeverities = map[string]int{
+	fatalLevel:        2,
+	errorLevel:        3,
+	warningLevel:      4,
+	importantLevel:    5,
+	infoLevel:         6,
+	decisionLevel:     6,
+	queryLevel:        7,
+	serviceDebugLevel: 7,
+}
+
+/*	SeverityNumber OpenTelemetry: DEBUG 5-8, INFO 9-12, WARN 13-16, ERROR 17-20, FATAL 21-24  */
+var gOTelSeverities = map[string]int{
+	fatalLevel:        21,
+	errorLevel:        17,
+	warningLevel:      13,
+	importantLevel:    12,
+	decisionLevel:     10,
+	infoLevel:         9,
+	queryLevel:        6,
+	serviceDebugLevel: 5,
+}
+
+var gNamings = map[string]*namingType{
+	namingNative: {
+		stampKey:      "stamp",
+		timeKey:       "time",
+		timeFormat:    timeFormatLayout,
+		levelKey:      "level",
+		errorKey:      "error",
+		callerKey:     "caller",
+		functionKey:   "func",
+		stackKey:      "stack",
+		stackAsFrames: true,
+		messageKey:    "message",
+	},
+	namingECS: {
+		timeKey:         "@timestamp",
+		timeFormat:      timeFormatRFC3339,
+		levelKey:        "log.level",
+		severityKey:     "log.syslog.severity.code",
+		severities:      gSyslogSeverities,
+		errorCodeKey:    "error.code",
+		errorTypeKey:    "error.type",
+		errorMessageKey: "error.message",
+		errorCausesKey:  "error.causes",
+		fileKey:         "log.origin.file.name",
+		lineKey:         "log.origin.file.line",
+		functionKey:     "log.origin.function",
+		stackKey:        "error.stack_trace",
+		messageKey:      "message",
+	},
+	namingOTel: {
+		timeKey:         "time_unix_nano",
+		timeFormat:      timeFormatNano,
+		levelKey:        "severity_text",
+		severityKey:     "severity_number",
+		severities:      gOTelSeverities,
+		errorCodeKey:    "error.code",
+		errorTypeKey:    "exception.type",
+		errorMessageKey: "exception.message",
+		errorCausesKey:  "exception.causes",
+		fileKey:         "code.filepath",
+		lineKey:         "code.lineno",
+		functionKey:     "code.function",
+		stackKey:        "exception.stacktrace",
+		messageKey:      "body",
+	},
+}
+
+/*	Пустое имя - собственный формат модуля (native)  */
+func parseNaming(name string) (*namingType, error) {
+	if name == "" {
+		name = namingNative
+	}
+	naming, isExists := gNamings[strings.ToLower(name)]
+	if isExists == false {
+		return nil, fmt.Errorf("Параметр FieldNaming конфигурации модуля flogger может быть только native, ecs, otel (задан %s)", name)
+	}
+	return naming, nil
+}
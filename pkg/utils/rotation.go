@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	RotationWeekly  = "weekly"
+	RotationMonthly = "monthly"
+
+	minRotationInterval = time.Minute
+	day                 = 24 * time.Hour
+)
+
+/*	Интервал смены файла логгирования. Границы интервалов всегда выровнены:
+**	интервалы меньше суток отсчитываются от полуночи по часам (в день перехода на летнее время границы
+**	не сдвигаются, а интервал с переходом короче или длиннее), интервалы кратные суткам - от понедельника
+**	05.01.1970 (поэтому неделя всегда начинается с понедельника), месяц - с первого числа  */
+type RotationIntervalType struct {
+	duration    time.Duration
+	monthly     bool
+	legacyHours uint // старое поведение параметра MaxHoursToChangeLogFile (см. CalcHour)
+}
+
+/*	Принимает длительность в формате Go (15m, 1h, 24h) либо ключевое слово weekly / monthly  */
+func ParseRotationInterval(value string) (RotationIntervalType, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case RotationWeekly:
+		return RotationIntervalType{duration: 7 * day}, nil
+	case RotationMonthly:
+		return RotationIntervalType{monthly: true}, nil
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return RotationIntervalType{}, fmt.Errorf("Не смог распарсить интервал смены файла логгирования %q: %w", value, err)
+	}
+	if duration < minRotationInterval {
+		return RotationIntervalType{}, fmt.Errorf("Интервал смены файла логгирования %s меньше минимально допустимого %s", duration, minRotationInterval)
+	}
+	if duration < day && day%duration != 0 {
+		return RotationIntervalType{}, fmt.Errorf("Интервал смены файла логгирования %s должен делить сутки без остатка", duration)
+	}
+	if duration > day && duration%day != 0 {
+		return RotationIntervalType{}, fmt.Errorf("Интервал смены файла логгирования %s должен быть кратен суткам", duration)
+	}
+	return RotationIntervalType{duration: duration}, nil
+}
+
+/*	Интервал совместимый с параметром MaxHoursToChangeLogFile (maxHours больше нуля)  */
+func NewHoursRotationInterval(maxHours uint) RotationIntervalType {
+	return RotationIntervalType{legacyHours: maxHours}
+}
+
+/*	Возвращает начало интервала в который попадает переданное время  */
+func (this RotationIntervalType) BucketStart(now time.Time) time.Time {
+	year, month, date := now.Date()
+	if this.monthly == true {
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	}
+	if this.legacyHours != 0 {
+		return time.Date(year, month, date, CalcHour(now.Hour(), int(this.legacyHours)), 0, 0, 0, now.Location())
+	}
+	if this.duration <= day {
+		/*	Время от полуночи по часам, а не прошедшее - иначе в день перехода на летнее время границы сдвигаются  */
+		hour, minute, second := now.Clock()
+		wall := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second + time.Duration(now.Nanosecond())
+		return time.Date(year, month, date, 0, 0, 0, int(wall-wall%this.duration), now.Location())
+	}
+	/*	Считаю дни по календарю (а не по прошедшим секундам) чтобы переход на летнее время не сдвигал границы  */
+	days := int64(this.duration / day)
+	epochDay := time.Date(year, month, date, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second)
+	offset := (epochDay - 4) % days // 05.01.1970 - первый понедельник от начала эпохи
+	if offset < 0 {
+		offset += days
+	}
+	return time.Date(year, month, date-int(offset), 0, 0, 0, 0, now.Location())
+}
+
+/*	Интервалы меньше часа требуют минут в названии файла  */
+func (this RotationIntervalType) HasMinutes() bool {
+	return this.monthly == false && this.legacyHours == 0 && this.duration%time.Hour != 0
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRotationInterval(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		isValid bool
+	}{
+		{name: "10m", value: "10m", isValid: true},
+		{name: "1h", value: "1h", isValid: true},
+		{name: "24h", value: "24h", isValid: true},
+		{name: "48h", value: "48h", isValid: true},
+		{name: "weekly", value: "weekly", isValid: true},
+		{name: "monthly", value: "Monthly", isValid: true},
+		{name: "too small", value: "30s", isValid: false},
+		{name: "not divides day", value: "7h", isValid: false},
+		{name: "not multiple of day", value: "36h", isValid: false},
+		{name: "garbage", value: "often", isValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRotationInterval(tc.value)
+			if tc.isValid == true && err != nil {
+				t.Errorf("Fail: unexpected error %s", err)
+			}
+			if tc.isValid == false && err == nil {
+				t.Errorf("Fail: expected error for %q", tc.value)
+			}
+		})
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval string
+		now      string
+		expected string
+	}{
+		{name: "10m", interval: "10m", now: "2022-09-30T15:47:13", expected: "2022-09-30T15:40:00"},
+		{name: "15m", interval: "15m", now: "2022-09-30T00:14:59", expected: "2022-09-30T00:00:00"},
+		{name: "6h", interval: "6h", now: "2022-09-30T17:00:00", expected: "2022-09-30T12:00:00"},
+		{name: "24h", interval: "24h", now: "2022-09-30T23:59:59", expected: "2022-09-30T00:00:00"},
+		{name: "weekly friday", interval: "weekly", now: "2022-09-30T10:00:00", expected: "2022-09-26T00:00:00"},
+		{name: "weekly monday", interval: "weekly", now: "2022-09-26T00:00:00", expected: "2022-09-26T00:00:00"},
+		{name: "weekly sunday", interval: "168h", now: "2022-10-02T23:00:00", expected: "2022-09-26T00:00:00"},
+		{name: "monthly", interval: "monthly", now: "2022-09-30T10:00:00", expected: "2022-09-01T00:00:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interval, err := ParseRotationInterval(tc.interval)
+			if err != nil {
+				t.Errorf("Error: %s", err)
+				t.FailNow()
+			}
+			now, err := time.Parse("2006-01-02T15:04:05", tc.now)
+			if err != nil {
+				t.Errorf("Error: %s", err)
+				t.FailNow()
+			}
+			if result := interval.BucketStart(now).Format("2006-01-02T15:04:05"); result != tc.expected {
+				t.Errorf("Fail: expected %s got %s", tc.expected, result)
+			}
+		})
+	}
+
+	t.Run("daylight saving time", func(t *testing.T) {
+		location, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skipf("No time zone database: %s", err)
+		}
+		interval, err := ParseRotationInterval("6h")
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			t.FailNow()
+		}
+		/*	В 02:00 часы переводятся на 03:00 - прошло 12 часов от полуночи, а на часах 13:00  */
+		now := time.Date(2022, 3, 13, 13, 0, 0, 0, location)
+		if result := interval.BucketStart(now).Format("15:04"); result != "12:00" {
+			t.Errorf("Fail: expected 12:00 got %s", result)
+		}
+		if result := NewHoursRotationInterval(12).BucketStart(now).Format("15:04"); result != "12:00" {
+			t.Errorf("Fail: expected 12:00 got %s", result)
+		}
+	})
+
+	t.Run("legacy hours", func(t *testing.T) {
+		now, err := time.Parse("2006-01-02T15:04:05", "2022-09-30T22:10:00")
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			t.FailNow()
+		}
+		if result := NewHoursRotationInterval(23).BucketStart(now).Hour(); result != 0 {
+			t.Errorf("Fail: expected %d got %d", 0, result)
+		}
+	})
+}
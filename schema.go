@@ -0,0 +1,117 @@
+package flogger
+
+import (
+	"encoding/json"
+)
+
+/*	Версия формата записей. Увеличивается при любом изменении набора, имен или типов полей записи -
+**	вместе с ней меняется и опубликованная схема record.schema.json  */
+const SchemaVersion = 1
+
+/*	JSON Schema записи json энкодера. Схема формируется из кода (имена уровней, поля ошибки и стека), поэтому
+**	опубликованный файл record.schema.json не может незаметно разойтись с реальным форматом - это проверяет тест.
+**	Пользовательские и статические поля в схеме не описаны (additionalProperties)  */
+func JSONSchema() []byte {
+	var levels []string
+	for level := LevelType(0); level < levelCount; level++ {
+		if containsString(levels, gLevelNames[level]) == false {
+			levels = append(levels, gLevelNames[level])
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         "https://github.com/GlobchanskyDenis/file_logger/record.schema.json",
+		"title":       "flogger record",
+		"description": "Запись json энкодера модуля flogger. Пользовательские и статические поля пишутся на верхнем уровне записи",
+		"type":        "object",
+		"required":    []string{"stamp", "time", "level", "message"},
+		"properties": map[string]interface{}{
+			"v": map[string]interface{}{
+				"description": "Версия формата записи (параметр SchemaVersionField)",
+				"const":       SchemaVersion,
+			},
+			"stamp": map[string]interface{}{
+				"description": "Unix время в единицах параметра TimestampUnit",
+				"type":        "integer",
+			},
+			"time": map[string]interface{}{
+				"description": "Время в формате параметра TimeLayout",
+				"type":        "string",
+			},
+			"level": map[string]interface{}{
+				"type": "string",
+				"enum": levels,
+			},
+			"error": map[string]interface{}{
+				"$ref": "#/$defs/error",
+			},
+			"caller": map[string]interface{}{
+				"description": "Место вызова pkg/file.go:123 (параметр CallerLevels)",
+				"type":        "string",
+			},
+			"func": map[string]interface{}{
+				"description": "Функция места вызова (параметр CallerWithFunc)",
+				"type":        "string",
+			},
+			"stack": map[string]interface{}{
+				"description": "Стек (параметр StackLevels)",
+				"type":        "array",
+				"items":       map[string]interface{}{"$ref": "#/$defs/frame"},
+			},
+			"message": map[string]interface{}{
+				"type": "string",
+			},
+		},
+		"additionalProperties": true,
+		"$defs": map[string]interface{}{
+			"error": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"message"},
+				"properties": map[string]interface{}{
+					"code": map[string]interface{}{
+						"type":    "integer",
+						"minimum": 0,
+					},
+					"type": map[string]interface{}{
+						"type": "string",
+					},
+					"message": map[string]interface{}{
+						"type": "string",
+					},
+					"causes": map[string]interface{}{
+						"description": "Обернутые ошибки (параметр ErrorCausesDepth)",
+						"type":        "array",
+						"items":       map[string]interface{}{"$ref": "#/$defs/error"},
+					},
+				},
+				"dependentRequired": map[string]interface{}{
+					"code": []string{"type"},
+					"type": []string{"code"},
+				},
+				"additionalProperties": false,
+			},
+			"frame": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"func", "file", "line"},
+				"properties": map[string]interface{}{
+					"func": map[string]interface{}{"type": "string"},
+					"file": map[string]interface{}{"type": "string"},
+					"line": map[string]interface{}{"type": "integer"},
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+	result, _ := json.MarshalIndent(schema, "", "\t")
+	return append(result, '\n')
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
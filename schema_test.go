@@ -0,0 +1,189 @@
+package flogger
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*	go test -run TestJSONSchema -update-schema  - перезаписывает опубликованную схему  */
+var gUpdateSchema = flag.Bool("update-schema", false, "перезаписать record.schema.json")
+
+const schemaFileName = "record.schema.json"
+
+func TestJSONSchema(t *testing.T) {
+	generated := JSONSchema()
+	if *gUpdateSchema == true {
+		if err := os.WriteFile(schemaFileName, generated, 0644); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+	}
+
+	t.Run("published", func(t *testing.T) {
+		published, err := os.ReadFile(schemaFileName)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if string(published) != string(generated) {
+			t.Errorf("%sFail: формат записи изменился - увеличьте SchemaVersion и обновите схему (go test -run TestJSONSchema -update-schema)%s", RED_BG, NO_COLOR)
+		}
+	})
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(generated, &schema); err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+
+	format := *gDefaultFormat
+	format.withVersion = true
+	static, err := newStaticFields(&ConfigType{StaticFields: map[string]string{"env": "test"}}, &format)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	now := time.Unix(100500, 0)
+	testCases := []struct {
+		name    string
+		record  RecordType
+		isValid bool
+	}{
+		{
+			name:    "minimal",
+			record:  RecordType{Time: now, Level: infoLevel, Message: "message"},
+			isValid: true,
+		},
+		{
+			name: "full record",
+			record: RecordType{
+				Time:     now,
+				Level:    errorLevel,
+				Static:   static,
+				Error:    &ErrorType{Code: 42, Type: "Business", Message: "top", Causes: []ErrorType{{Message: "inner"}}},
+				Caller:   "pkg/file.go:12",
+				Function: "pkg.f",
+				Stack:    []StackFrameType{{Function: "pkg.f", File: "pkg/file.go", Line: 12}},
+				Fields:   []FieldType{{Key: "a", Value: []interface{}{1, "b"}}},
+				Message:  "message",
+			},
+			isValid: true,
+		},
+		{
+			name:    "unknown level",
+			record:  RecordType{Time: now, Level: "TRACE", Message: "message"},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.record.format = &format
+			var record interface{}
+			if err := json.Unmarshal(jsonEncoderType{}.Encode(nil, &tc.record), &record); err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+				t.FailNow()
+			}
+			if version := record.(map[string]interface{})["v"]; version != float64(SchemaVersion) {
+				t.Errorf("%sFail: expected v=%d got %v%s", RED_BG, SchemaVersion, version, NO_COLOR)
+			}
+			err := validateSchema(schema, schema, record, "")
+			if tc.isValid == true && err != nil {
+				t.Errorf("%sFail: %s%s", RED_BG, err, NO_COLOR)
+			}
+			if tc.isValid == false && err == nil {
+				t.Errorf("%sFail: expected validation error%s", RED_BG, NO_COLOR)
+			}
+		})
+	}
+}
+
+/*	Минимальный валидатор - только ключевые слова которые используются в схеме записи  */
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) error {
+	if ref, isExists := schema["$ref"].(string); isExists == true {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		return validateSchema(root, root["$defs"].(map[string]interface{})[name].(map[string]interface{}), value, path)
+	}
+	if expected, isExists := schema["const"]; isExists == true && fmt.Sprint(expected) != fmt.Sprint(value) {
+		return fmt.Errorf("%s: expected %v got %v", path, expected, value)
+	}
+	if enum, isExists := schema["enum"].([]interface{}); isExists == true {
+		var isFound bool
+		for _, item := range enum {
+			isFound = isFound || item == value
+		}
+		if isFound == false {
+			return fmt.Errorf("%s: %v is not in enum", path, value)
+		}
+	}
+	if minimum, isExists := schema["minimum"].(float64); isExists == true && value.(float64) < minimum {
+		return fmt.Errorf("%s: %v is less than %v", path, value, minimum)
+	}
+	switch schema["type"] {
+	case "string":
+		if _, isOk := value.(string); isOk == false {
+			return fmt.Errorf("%s: expected string", path)
+		}
+	case "integer":
+		if number, isOk := value.(float64); isOk == false || number != float64(int64(number)) {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+	case "array":
+		list, isOk := value.([]interface{})
+		if isOk == false {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		for i, item := range list {
+			if err := validateSchema(root, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, isOk := value.(map[string]interface{})
+		if isOk == false {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		return validateSchemaObject(root, schema, object, path)
+	}
+	return nil
+}
+
+func validateSchemaObject(root, schema map[string]interface{}, object map[string]interface{}, path string) error {
+	required, _ := schema["required"].([]interface{})
+	for _, key := range required {
+		if _, isExists := object[key.(string)]; isExists == false {
+			return fmt.Errorf("%s: required field %s is missing", path, key)
+		}
+	}
+	dependentRequired, _ := schema["dependentRequired"].(map[string]interface{})
+	for key, dependencies := range dependentRequired {
+		if _, isExists := object[key]; isExists == false {
+			continue
+		}
+		for _, dependency := range dependencies.([]interface{}) {
+			if _, isExists := object[dependency.(string)]; isExists == false {
+				return fmt.Errorf("%s: field %s requires %s", path, key, dependency)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for key, value := range object {
+		property, isExists := properties[key].(map[string]interface{})
+		if isExists == false {
+			if schema["additionalProperties"] == false {
+				return errors.New(path + ": unexpected field " + key)
+			}
+			continue
+		}
+		if err := validateSchema(root, property, value, path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package flogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+/*	Уровни slog для QUERY, DECISION и IMPORTANT по умолчанию - между стандартными уровнями slog  */
+const (
+	SlogLevelQuery     slog.Level = -2 // между Debug и Info
+	SlogLevelDecision  slog.Level = 2  // между Info и Warn
+	SlogLevelImportant slog.Level = 6  // между Warn и Error
+)
+
+/*	Количество фреймов slog между LoggerType.log и пользовательским кодом
+**	(SlogHandlerType.Handle -> slog.Logger.log -> slog.Logger.Info)  */
+const slogSkip = 2
+
+/*	Уровни slog которые пишутся как QUERY, DECISION и IMPORTANT. Уровень сравнивается точно, поэтому
+**	значения должны отличаться друг от друга и от стандартных уровней slog. Незаданное (нулевое) значение
+**	совпадает с slog.LevelInfo, поэтому заменяется уровнем по умолчанию  */
+type SlogOptionsType struct {
+	QueryLevel     slog.Level
+	DecisionLevel  slog.Level
+	ImportantLevel slog.Level
+}
+
+/*	Реализация slog.Handler поверх логгера - записи slog попадают в те же файлы, включаются теми же
+**	параметрами Enable* и вызывают те же триггеры мониторинга. Уровни ниже Info пишутся как ServiceDebug,
+**	начиная с Info - Info, с Warn - Warning, с Error - Error.
+**	Группы (WithGroup, slog.Group) пишутся вложенными объектами. Первый атрибут-ошибка верхнего уровня
+**	становится ошибкой записи (через errorHandler логгера), остальные ошибки пишутся сообщением errorHandler  */
+type SlogHandlerType struct {
+	logger  *LoggerType
+	options SlogOptionsType
+	chain   []slogChainItemType // атрибуты и группы добавленные через WithAttrs и WithGroup по порядку
+}
+
+/*	Элемент цепочки - либо группа, либо атрибуты  */
+type slogChainItemType struct {
+	group string
+	attrs []slog.Attr
+}
+
+var _ slog.Handler = (*SlogHandlerType)(nil)
+
+/*	options == nil или незаданные в options уровни - уровни по умолчанию (SlogLevelQuery, SlogLevelDecision,
+**	SlogLevelImportant)  */
+func NewSlogHandler(logger *LoggerType, options *SlogOptionsType) *SlogHandlerType {
+	handler := &SlogHandlerType{
+		logger: logger,
+		options: SlogOptionsType{
+			QueryLevel:     SlogLevelQuery,
+			DecisionLevel:  SlogLevelDecision,
+			ImportantLevel: SlogLevelImportant,
+		},
+	}
+	if options != nil {
+		if options.QueryLevel != 0 {
+			handler.options.QueryLevel = options.QueryLevel
+		}
+		if options.DecisionLevel != 0 {
+			handler.options.DecisionLevel = options.DecisionLevel
+		}
+		if options.ImportantLevel != 0 {
+			handler.options.ImportantLevel = options.ImportantLevel
+		}
+	}
+	return handler
+}
+
+func (this *SlogHandlerType) Enabled(_ context.Context, level slog.Level) bool {
+	return this.logger.isEnabled(this.toLevel(level))
+}
+
+/*	Запись проходит тот же путь что и записи методов логгера. Место вызова и время берутся из записи slog.
+**	Поля извлекаются из контекста так же как в методах InfoCtx...  */
+func (this *SlogHandlerType) Handle(ctx context.Context, record slog.Record) error {
+	entry := entryType{
+		ctx:      ctx,
+		level:    this.toLevel(record.Level),
+		message:  record.Message,
+		callerPC: record.PC,
+		time:     record.Time,
+		skip:     slogSkip,
+	}
+	entry.targets = this.logger.levelTargets(entry.level)
+	if entry.targets.isEnabled() == true {
+		entry.fieldList = this.collectFields(this.chain, &record, &entry.err)
+	}
+	this.logger.log(entry)
+	return nil
+}
+
+func (this *SlogHandlerType) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return this
+	}
+	return this.withChainItem(slogChainItemType{attrs: attrs})
+}
+
+func (this *SlogHandlerType) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return this
+	}
+	return this.withChainItem(slogChainItemType{group: name})
+}
+
+/*	Цепочка родителя не меняется - ее копия дополняется новым элементом  */
+func (this *SlogHandlerType) withChainItem(item slogChainItemType) *SlogHandlerType {
+	child := *this
+	child.chain = append(append(make([]slogChainItemType, 0, len(this.chain)+1), this.chain...), item)
+	return &child
+}
+
+func (this *SlogHandlerType) toLevel(level slog.Level) LevelType {
+	switch {
+	case level == this.options.QueryLevel:
+		return levelQuery
+	case level == this.options.DecisionLevel:
+		return levelDecision
+	case level == this.options.ImportantLevel:
+		return levelImportant
+	case level >= slog.LevelError:
+		return levelError
+	case level >= slog.LevelWarn:
+		return levelWarning
+	case level >= slog.LevelInfo:
+		return levelInfo
+	default:
+		return levelServiceDebug
+	}
+}
+
+/*	Атрибуты после группы (и атрибуты записи) попадают внутрь группы. Пустая группа не пишется.
+**	err != nil - атрибут-ошибка на этом уровне может стать ошибкой записи  */
+func (this *SlogHandlerType) collectFields(chain []slogChainItemType, record *slog.Record, err *error) []FieldType {
+	var fields []FieldType
+	for i, item := range chain {
+		if item.group != "" {
+			if nested := this.collectFields(chain[i+1:], record, nil); len(nested) > 0 {
+				fields = append(fields, FieldType{Key: item.group, Value: nested})
+			}
+			return fields
+		}
+		for _, attr := range item.attrs {
+			fields = this.appendAttr(fields, attr, err)
+		}
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = this.appendAttr(fields, attr, err)
+		return true
+	})
+	return fields
+}
+
+func (this *SlogHandlerType) appendAttr(fields []FieldType, attr slog.Attr, err *error) []FieldType {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) == true {
+		return fields
+	}
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		groupAttrs := attr.Value.Group()
+		/*	Группа без ключа встраивается в текущий уровень  */
+		if attr.Key == "" {
+			for _, groupAttr := range groupAttrs {
+				fields = this.appendAttr(fields, groupAttr, err)
+			}
+			return fields
+		}
+		var nested []FieldType
+		for _, groupAttr := range groupAttrs {
+			nested = this.appendAttr(nested, groupAttr, nil)
+		}
+		if len(nested) > 0 {
+			fields = append(fields, FieldType{Key: attr.Key, Value: nested})
+		}
+		return fields
+	case slog.KindAny:
+		if attrErr, isError := attr.Value.Any().(error); isError == true && attrErr != nil {
+			if err != nil && *err == nil {
+				*err = attrErr
+				return fields
+			}
+			return append(fields, FieldType{Key: attr.Key, Value: this.logger.errorMessage(attrErr)})
+		}
+	}
+	return append(fields, slogField(attr.Key, attr.Value))
+}
+
+/*	Значение slog в поле - основные виды без рефлексии и без упаковки в interface{}  */
+func slogField(key string, value slog.Value) FieldType {
+	switch value.Kind() {
+	case slog.KindString:
+		return String(key, value.String())
+	case slog.KindInt64:
+		return Int64(key, value.Int64())
+	case slog.KindUint64:
+		return FieldType{Key: key, Value: value.Uint64()}
+	case slog.KindFloat64:
+		return FieldType{Key: key, Value: value.Float64()}
+	case slog.KindBool:
+		return Bool(key, value.Bool())
+	case slog.KindDuration:
+		return Duration(key, value.Duration())
+	case slog.KindTime:
+		return Time(key, value.Time())
+	default:
+		return FieldType{Key: key, Value: value.Any()}
+	}
+}
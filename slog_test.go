@@ -0,0 +1,119 @@
+package flogger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.EnableDecision = true
+	loggerConf.CallerLevels = []string{"WARNING"}
+	loggerConf.StackLevels = []string{"WARNING"}
+	loggerConf.StackDepth = 1
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	logger.SetErrorHandler(func(err error) (uint, string, string) {
+		return 7, "Internal", "handled: " + err.Error()
+	})
+
+	slogger := slog.New(NewSlogHandler(logger, nil)).With("service_part", "api")
+	slogger.WithGroup("request").With("id", 5).Info("request done", "status", 200, slog.Group("timing", "db_ms", 3))
+	slogger.Error("request failed", "err", errors.New("timeout"), "cause", errors.New("dial"))
+	slogger.Warn("slow request", slog.Group("", "duration_ms", 1500))
+	slogger.Log(context.Background(), SlogLevelDecision, "retry skipped", "attempt", uint64(3))
+	slogger.Debug("disabled level")
+	slogger.WithGroup("empty").Info("empty group")
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 5 {
+		t.Errorf("%sFail: expected 5 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	var testCases = []struct {
+		name     string
+		expected []string
+	}{
+		{
+			name:     "groups",
+			expected: []string{`"level":"INFO","request":{"id":5,"status":200,"timing":{"db_ms":3}},"service_part":"api","message":"request done"}`},
+		},
+		{
+			name: "error attribute",
+			expected: []string{
+				`"level":"ERROR","error":{"code":7,"type":"Internal","message":"handled: timeout"}`,
+				`"cause":"handled: dial","service_part":"api","message":"request failed"}`,
+			},
+		},
+		{
+			name:     "inline group and caller",
+			expected: []string{`"level":"WARNING","caller":"`, `/slog_test.go:33"`, `/slog_test.go","line":33}]`, `"duration_ms":1500,"service_part":"api","message":"slow request"}`},
+		},
+		{
+			name:     "custom level",
+			expected: []string{`"level":"DECISION","attempt":3,"service_part":"api","message":"retry skipped"}`},
+		},
+		{
+			name:     "empty group",
+			expected: []string{`"level":"INFO","service_part":"api","message":"empty group"}`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, expected := range tc.expected {
+				if strings.Contains(lines[i], expected) == false {
+					t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[i], NO_COLOR)
+				}
+			}
+		})
+	}
+}
+
+func TestSlogLevels(t *testing.T) {
+	handler := NewSlogHandler(&LoggerType{}, &SlogOptionsType{QueryLevel: -8, DecisionLevel: 1, ImportantLevel: 12})
+	var testCases = []struct {
+		level    slog.Level
+		expected LevelType
+	}{
+		{level: slog.LevelDebug - 4, expected: levelQuery},
+		{level: slog.LevelDebug, expected: levelServiceDebug},
+		{level: slog.LevelInfo, expected: levelInfo},
+		{level: 1, expected: levelDecision},
+		{level: slog.LevelWarn, expected: levelWarning},
+		{level: slog.LevelError, expected: levelError},
+		{level: 12, expected: levelImportant},
+		{level: 16, expected: levelError},
+	}
+	for _, tc := range testCases {
+		if level := handler.toLevel(tc.level); level != tc.expected {
+			t.Errorf("%sFail: level %d expected %s got %s%s", RED_BG, tc.level, tc.expected, level, NO_COLOR)
+		}
+	}
+	/*	Незаданные уровни - по умолчанию, Info остается Info  */
+	handler = NewSlogHandler(&LoggerType{}, &SlogOptionsType{QueryLevel: -8})
+	testCases = testCases[:0]
+	testCases = append(testCases, []struct {
+		level    slog.Level
+		expected LevelType
+	}{
+		{level: -8, expected: levelQuery},
+		{level: slog.LevelInfo, expected: levelInfo},
+		{level: SlogLevelDecision, expected: levelDecision},
+		{level: SlogLevelImportant, expected: levelImportant},
+	}...)
+	for _, tc := range testCases {
+		if level := handler.toLevel(tc.level); level != tc.expected {
+			t.Errorf("%sFail: level %d expected %s got %s%s", RED_BG, tc.level, tc.expected, level, NO_COLOR)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package flogger
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+/*	Глубина стека по умолчанию если параметр StackDepth не задан  */
+const defaultStackDepth = 32
+
+/*	Кадр стека в записи  */
+type StackFrameType struct {
+	Function string
+	File     string
+	Line     int
+}
+
+/*	Ошибка может сама хранить стек места своего создания. Такой стек полезнее стека места логгирования,
+**	поэтому используется в первую очередь. Поддерживаются интерфейс IStackTracer и метод StackTrace()
+**	из github.com/pkg/errors (слайс кадров - адресов вызова), зависимость от этого пакета не нужна  */
+type IStackTracer interface {
+	StackPCs() []uintptr
+}
+
+/*	Запоминает адреса вызовов - стек ошибки если он есть, иначе стек места логгирования  */
+func captureStack(err error, skip int, depth int) []uintptr {
+	if pcs := errorStack(err); len(pcs) > 0 {
+		if len(pcs) > depth {
+			pcs = pcs[:depth]
+		}
+		return pcs
+	}
+	var pcs = make([]uintptr, depth)
+	return pcs[:runtime.Callers(callerBaseSkip+skip, pcs)]
+}
+
+/*	Ищет стек по всей цепочке обернутых ошибок - самая глубокая ошибка со стеком ближе всего к месту возникновения  */
+func errorStack(err error) []uintptr {
+	var result []uintptr
+	for err != nil {
+		if pcs := extractStack(err); len(pcs) > 0 {
+			result = pcs
+		}
+		err = errors.Unwrap(err)
+	}
+	return result
+}
+
+/*	Методы ошибки пользовательские - паника в них (например nil указатель внутри ошибки) не должна ронять
+**	логгирование, в этом случае стека ошибки просто нет  */
+func extractStack(err error) (pcs []uintptr) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			pcs = nil
+		}
+	}()
+	if tracer, isTracer := err.(IStackTracer); isTracer == true {
+		return tracer.StackPCs()
+	}
+	/*	func (e) StackTrace() errors.StackTrace, где StackTrace - []Frame, а Frame - uintptr  */
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if method.IsValid() == false || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	outType := method.Type().Out(0)
+	if outType.Kind() != reflect.Slice || outType.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	frames := method.Call(nil)[0]
+	pcs = make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
+
+/*	Имена файлов и функций вычисляются уже в горутине записи  */
+func resolveStack(pcs []uintptr) []StackFrameType {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var stack = make([]StackFrameType, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, isMore := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			stack = append(stack, StackFrameType{
+				Function: trimPath(frame.Function, 1),
+				File:     trimPath(frame.File, 2),
+				Line:     frame.Line,
+			})
+		}
+		if isMore == false {
+			return stack
+		}
+	}
+}
+
+/*	Стек в одну строку для текстовых форматов: "pkg.f (pkg/file.go:12); pkg.g (pkg/file.go:30)"  */
+func stackToString(stack []StackFrameType) string {
+	var dst []byte
+	for i, frame := range stack {
+		if i > 0 {
+			dst = append(dst, "; "...)
+		}
+		dst = append(dst, frame.Function...)
+		dst = append(dst, " ("...)
+		dst = append(dst, frame.File...)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(frame.Line), 10)
+		dst = append(dst, ')')
+	}
+	return string(dst)
+}
@@ -0,0 +1,126 @@
+package flogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+/*	Ошибка со стеком в стиле github.com/pkg/errors  */
+type testFrameType uintptr
+
+type testStackErrorType struct {
+	pcs []uintptr
+}
+
+func (this testStackErrorType) Error() string {
+	return "error with stack"
+}
+
+func (this testStackErrorType) StackTrace() []testFrameType {
+	var frames = make([]testFrameType, len(this.pcs))
+	for i, pc := range this.pcs {
+		frames[i] = testFrameType(pc)
+	}
+	return frames
+}
+
+func newTestStackError() error {
+	var pcs = make([]uintptr, 32)
+	return testStackErrorType{pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+/*	Ошибка у которой метод стека паникует  */
+type testPanicStackErrorType struct {
+	pcs *[]uintptr
+}
+
+func (this testPanicStackErrorType) Error() string {
+	return "error with broken stack"
+}
+
+func (this testPanicStackErrorType) StackPCs() []uintptr {
+	return *this.pcs
+}
+
+func TestStack(t *testing.T) {
+	t.Run("call site stack", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.StackLevels = []string{"ERROR"}
+		loggerConf.StackDepth = 2
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.Error(nil, errors.New("plain error"), "with stack")
+		logger.Warning(nil, nil, "without stack")
+
+		lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+		if len(lines) != 2 {
+			t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+			t.FailNow()
+		}
+		var record struct {
+			Stack []struct {
+				Func string `json:"func"`
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"stack"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if len(record.Stack) != 2 {
+			t.Errorf("%sFail: expected 2 frames got %d%s", RED_BG, len(record.Stack), NO_COLOR)
+			t.FailNow()
+		}
+		if strings.HasPrefix(record.Stack[0].Func, "file_logger.TestStack") == false || strings.HasSuffix(record.Stack[0].File, "stack_test.go") == false || record.Stack[0].Line == 0 {
+			t.Errorf("%sFail: unexpected first frame %+v%s", RED_BG, record.Stack[0], NO_COLOR)
+		}
+		if strings.Contains(lines[1], `"stack"`) == true {
+			t.Errorf("%sFail: stack is not expected in %s%s", RED_BG, lines[1], NO_COLOR)
+		}
+	})
+
+	t.Run("error stack", func(t *testing.T) {
+		stackErr := newTestStackError()
+		pcs := captureStack(fmt.Errorf("wrapped: %w", stackErr), 0, defaultStackDepth)
+		stack := resolveStack(pcs)
+		if len(stack) == 0 || strings.HasSuffix(stack[0].Function, "newTestStackError") == false {
+			t.Errorf("%sFail: expected stack of error got %s%s", RED_BG, stackToString(stack), NO_COLOR)
+		}
+	})
+
+	t.Run("panic in error stack", func(t *testing.T) {
+		pcs := captureStack(fmt.Errorf("wrapped: %w", testPanicStackErrorType{}), 0, defaultStackDepth)
+		stack := resolveStack(pcs)
+		if len(stack) == 0 {
+			t.Errorf("%sFail: expected call site stack%s", RED_BG, NO_COLOR)
+		}
+	})
+
+	t.Run("single line", func(t *testing.T) {
+		record := RecordType{
+			Level:   "ERROR",
+			Stack:   []StackFrameType{{Function: "pkg.f", File: "pkg/file.go", Line: 12}, {Function: "pkg.g", File: "pkg/file.go", Line: 30}},
+			Message: "msg",
+		}
+		expected := ` stack="pkg.f (pkg/file.go:12); pkg.g (pkg/file.go:30)"`
+		if result := string(logfmtEncoderType{}.Encode(nil, &record)); strings.Contains(result, expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+		expected = `"stack":[{"func":"pkg.f","file":"pkg/file.go","line":12},{"func":"pkg.g","file":"pkg/file.go","line":30}],`
+		if result := string(jsonEncoderType{}.Encode(nil, &record)); strings.Contains(result, expected) == false || strings.Count(result, "\n") != 1 {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package flogger
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+)
+
+/*	Статические поля добавляются в каждую запись. Они сериализуются один раз при создании логгера,
+**	поэтому не стоят ничего при каждом вызове (в отличие от слияния мап с пользовательскими полями).
+**	Поле вызова, контекста или дочернего логгера с тем же ключом что у статического поля отбрасывается  */
+type StaticFieldsType struct {
+	Fields  []FieldType // отсортированы по ключу - для пользовательских энкодеров
+	json    []byte      // "key":value, (с запятой после каждого поля)
+	logfmt  []byte      // " key=value" (с пробелом перед каждым полем)
+	msgpack []byte      // пары ключ - значение без заголовка объекта
+}
+
+/*	Поставщики значений для параметра StaticProviders. Имя поставщика совпадает с ключом поля  */
+var gStaticProviders = map[string]func(conf *ConfigType) (interface{}, error){
+	"host": func(conf *ConfigType) (interface{}, error) {
+		return os.Hostname()
+	},
+	"pid": func(conf *ConfigType) (interface{}, error) {
+		return os.Getpid(), nil
+	},
+	"service": func(conf *ConfigType) (interface{}, error) {
+		return conf.ServiceName, nil
+	},
+	"version": func(conf *ConfigType) (interface{}, error) {
+		return buildVersion(), nil
+	},
+}
+
+/*	Ключи которые пишут сами энкодеры (json, logfmt, msgpack) и ограничения размеров - статическое поле с таким
+**	ключом дублировало бы их. Ключи профиля имен (FieldNaming) добавляются к ним  */
+var gReservedKeys = []string{"v", "stamp", "time", "level", "error", "error.code", "error.type", "error.message", "caller", "func", "file", "line", "stack", "message", "msg", truncatedFieldsKey, truncatedRecordKey}
+
+/*	Явно заданные поля (StaticFields) имеют приоритет над поставщиками. Поля сериализуются в формате логгера  */
+func newStaticFields(conf *ConfigType, format *formatType) (*StaticFieldsType, error) {
+	values := map[string]interface{}{}
+	for _, name := range conf.StaticProviders {
+		provider, isExists := gStaticProviders[name]
+		if isExists == false {
+			return nil, fmt.Errorf("Параметр StaticProviders конфигурации модуля flogger может содержать только host, pid, service, version (задан %s)", name)
+		}
+		value, err := provider(conf)
+		if err != nil {
+			return nil, fmt.Errorf("Не смог получить значение статического поля %s %w", name, err)
+		}
+		values[name] = value
+	}
+	for key, value := range conf.StaticFields {
+		values[key] = value
+	}
+	for key := range values {
+		if isReservedKey(key, format.naming) == true {
+			return nil, fmt.Errorf("Статическое поле %s конфигурации модуля flogger совпадает с ключом записи", key)
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	static := &StaticFieldsType{
+		Fields: make([]FieldType, 0, len(values)),
+	}
+	for key, value := range values {
+		static.Fields = append(static.Fields, FieldType{Key: key, Value: value})
+	}
+	sort.Slice(static.Fields, func(i, j int) bool {
+		return static.Fields[i].Key < static.Fields[j].Key
+	})
+	for _, field := range static.Fields {
+		static.json = appendJSONString(static.json, field.Key)
+		static.json = append(static.json, ':')
+		static.json = appendValue(static.json, field.Value, format)
+		static.json = append(static.json, ',')
+		static.logfmt = appendLogfmtField(static.logfmt, field.Key, field.Value, format)
+		static.msgpack = appendMsgpackString(static.msgpack, field.Key)
+		static.msgpack = appendMsgpackValue(static.msgpack, field.Value, &valuePathType{format: format})
+	}
+	return static, nil
+}
+
+func isReservedKey(key string, naming *namingType) bool {
+	for _, reserved := range gReservedKeys {
+		if key == reserved {
+			return true
+		}
+	}
+	for _, reserved := range []string{naming.stampKey, naming.timeKey, naming.levelKey, naming.severityKey, naming.errorKey, naming.errorCodeKey, naming.errorTypeKey, naming.errorMessageKey, naming.errorCausesKey, naming.callerKey, naming.fileKey, naming.lineKey, naming.functionKey, naming.stackKey, naming.messageKey} {
+		if key == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+/*	Статические поля уже сериализованы, поэтому из полей записи убираются поля с их ключами (иначе в json
+**	повторится ключ, а заголовок объекта msgpack не совпадет с количеством пар). Слайс может принадлежать
+**	вызывающему коду - при изменениях создается копия  */
+func (this *StaticFieldsType) dropStaticKeys(fields []FieldType) []FieldType {
+	if this == nil {
+		return fields
+	}
+	var result []FieldType
+	for i := range fields {
+		isStatic := containsFieldKey(this.Fields, fields[i].Key)
+		if isStatic == true && result == nil {
+			result = append(make([]FieldType, 0, len(fields)), fields[:i]...)
+		}
+		if isStatic == false && result != nil {
+			result = append(result, fields[i])
+		}
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+/*	Версия модуля если бинарник собран из тега, иначе ревизия системы контроля версий  */
+func buildVersion() string {
+	info, isOk := debug.ReadBuildInfo()
+	if isOk == false {
+		return ""
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return info.Main.Version
+}
@@ -0,0 +1,177 @@
+package flogger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStaticFields(t *testing.T) {
+	t.Run("every record", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.StaticFields = map[string]string{"env": "test", "pid": "overridden"}
+		loggerConf.StaticProviders = []string{"pid", "service"}
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		logger, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		logger.Info(map[string]interface{}{"a": 1}, "first")
+		logger.Warning(nil, nil, "second")
+
+		lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+		if len(lines) != 2 {
+			t.Errorf("%sFail: expected 2 records got %d%s", RED_BG, len(lines), NO_COLOR)
+			t.FailNow()
+		}
+		for _, line := range lines {
+			expected := `,"env":"test","pid":"overridden","service":` + strconv.Quote(loggerConf.ServiceName) + `,`
+			if strings.Contains(line, expected) == false {
+				t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, line, NO_COLOR)
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			}
+		}
+	})
+
+	t.Run("per logger", func(t *testing.T) {
+		loggerConf := newTestConfig(t)
+		loggerConf.StaticFields = map[string]string{"env": "first"}
+		loggerConf.TimestampUnit = "ns"
+		firstFolder := loggerConf.LogFolder
+		firstWg := &sync.WaitGroup{}
+		firstWg.Add(1)
+		first, err := NewLogger(firstWg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+
+		/*	Второй логгер со своими настройками не должен менять формат первого  */
+		loggerConf.LogFolder = t.TempDir()
+		loggerConf.StaticFields = map[string]string{"env": "second"}
+		loggerConf.TimestampUnit = ""
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		second, err := NewLogger(wg)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		first.Info(nil, "first")
+		second.Info(nil, "second")
+
+		fileName := first.defaultFile.fileName
+		first.Stop()
+		firstWg.Wait()
+		body, err := os.ReadFile(filepath.Join(firstFolder, fileName))
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		var record struct {
+			Stamp int64  `json:"stamp"`
+			Env   string `json:"env"`
+		}
+		if err := json.Unmarshal(body, &record); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if record.Env != "first" || record.Stamp < 1e18 {
+			t.Errorf("%sFail: first logger record %s%s", RED_BG, body, NO_COLOR)
+		}
+
+		body = []byte(stopAndReadLogFile(t, second, wg, "default"))
+		if err := json.Unmarshal(body, &record); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if record.Env != "second" || record.Stamp > 1e12 {
+			t.Errorf("%sFail: second logger record %s%s", RED_BG, body, NO_COLOR)
+		}
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		static, err := newStaticFields(&ConfigType{StaticFields: map[string]string{"env": "test", "host": "static"}}, gDefaultFormat)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		format := *gDefaultFormat
+		format.static = static
+		var dto = messageType{
+			LogLevel:    infoLevel,
+			Fields:      map[string]interface{}{"a": 1, "host": "call"},
+			BoundFields: []FieldType{String("env", "bound")},
+			Message:     "message",
+		}
+		record := dto.toRecord(&format, gDefaultLimits)
+		if expected := `"env":"test","host":"static","a":1,"message"`; strings.Contains(string(jsonEncoderType{}.Encode(nil, &record)), expected) == false {
+			t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, jsonEncoderType{}.Encode(nil, &record), NO_COLOR)
+		}
+		var result strings.Builder
+		if err := MsgpackToJSON(&result, strings.NewReader(string(msgpackEncoderType{}.Encode(nil, &record)))); err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		}
+		if expected := string(jsonEncoderType{}.Encode(nil, &record)); result.String() != expected {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, result.String(), NO_COLOR)
+		}
+	})
+
+	t.Run("reserved keys", func(t *testing.T) {
+		ecs, err := newFormat(&ConfigType{FieldNaming: "ecs"})
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		for _, tc := range []struct {
+			key    string
+			format *formatType
+		}{
+			{key: "message", format: gDefaultFormat},
+			{key: "level", format: gDefaultFormat},
+			{key: "msg", format: gDefaultFormat},
+			{key: "@timestamp", format: ecs},
+		} {
+			if _, err := newStaticFields(&ConfigType{StaticFields: map[string]string{tc.key: "x"}}, tc.format); err == nil {
+				t.Errorf("%sFail: expected error for %s%s", RED_BG, tc.key, NO_COLOR)
+			}
+		}
+	})
+
+	t.Run("providers", func(t *testing.T) {
+		static, err := newStaticFields(&ConfigType{StaticProviders: []string{"pid"}}, gDefaultFormat)
+		if err != nil {
+			t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+			t.FailNow()
+		}
+		if expected := `"pid":` + strconv.Itoa(os.Getpid()) + `,`; string(static.json) != expected {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, string(static.json), NO_COLOR)
+		}
+		if expected := ` pid=` + strconv.Itoa(os.Getpid()); string(static.logfmt) != expected {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, string(static.logfmt), NO_COLOR)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		static, err := newStaticFields(&ConfigType{StaticFields: map[string]string{}}, gDefaultFormat)
+		if err != nil || static != nil {
+			t.Errorf("%sFail: expected no static fields got %v %v%s", RED_BG, static, err, NO_COLOR)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		if _, err := newStaticFields(&ConfigType{StaticProviders: []string{"region"}}, gDefaultFormat); err == nil {
+			t.Errorf("%sFail: expected error%s", RED_BG, NO_COLOR)
+		}
+	})
+}
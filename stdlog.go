@@ -0,0 +1,232 @@
+package flogger
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+)
+
+/*	Пакеты через которые пользовательский код пишет в Writer (log.Logger.Printf, fmt.Fprintf, io.WriteString,
+**	bufio.Writer.Flush...) - их фреймы пропускаются при поиске места вызова  */
+var gStdWriterPackages = []string{"log.", "fmt.", "io.", "bufio."}
+
+/*	Флаги заголовка для io.Writer - флаги того кто пишет неизвестны, поэтому проверяются все части заголовка  */
+const stdHeaderFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile
+
+/*	Настройки StdLogger и Writer. nil - настройки по умолчанию (все выключено)  */
+type StdOptionsType struct {
+	/*	Строка которая начинается с уровня в квадратных скобках ([ERROR], [WARN], [DEBUG]...) пишется этим уровнем,
+	**	сам уровень убирается. Текст приходит из сторонней библиотеки, поэтому уровни с триггерами мониторинга
+	**	(FATAL, ERROR, IMPORTANT) выше уровня writer не принимаются - такая строка пишется уровнем writer как есть  */
+	ParseLevelToken bool
+}
+
+/*	io.Writer для библиотек которые принимают только *log.Logger или io.Writer. Каждая строка записи становится
+**	отдельной записью логгера. Части строки из разных вызовов Write не склеиваются  */
+type writerType struct {
+	logger  *LoggerType // дочерний логгер с привязанными полями
+	level   LevelType
+	options StdOptionsType
+	std     *log.Logger // стандартный логгер поверх этого writer - его префикс и флаги отрезаются
+}
+
+/*	Стандартный логгер (http.Server.ErrorLog, драйверы database/sql...) который пишет в этот логгер с уровнем level
+**	и полями fields. Заголовок который добавляет стандартный логгер (префикс, дата, время, файл) отрезается.
+**	Неизвестный level (не одна из констант Level*) заменяется на LevelInfo  */
+func (this *LoggerType) StdLogger(level LevelType, fields map[string]interface{}, options *StdOptionsType) *log.Logger {
+	writer := this.newWriter(level, fields, options)
+	writer.std = log.New(writer, "", 0)
+	return writer.std
+}
+
+/*	io.Writer который пишет каждую строку в этот логгер с уровнем level и полями fields. Заголовок в формате
+**	стандартного логгера (дата, время, файл) отрезается если строка с него начинается  */
+func (this *LoggerType) Writer(level LevelType, fields map[string]interface{}, options *StdOptionsType) io.Writer {
+	return this.newWriter(level, fields, options)
+}
+
+func (this *LoggerType) newWriter(level LevelType, fields map[string]interface{}, options *StdOptionsType) *writerType {
+	if level >= levelCount {
+		println("Warning: file logger got unknown level " + level.String() + " for std writer, INFO is used")
+		level = levelInfo
+	}
+	writer := &writerType{
+		logger: this.With(fields),
+		level:  level,
+	}
+	if options != nil {
+		writer.options = *options
+	}
+	return writer
+}
+
+/*	Логгер вызывается прямо отсюда. Место вызова - первый фрейм выше Write вне пакетов log, fmt, io, bufio
+**	(количество их фреймов зависит от метода - Printf, Panicf, Fatalf, fmt.Fprintf...)  */
+func (this *writerType) Write(p []byte) (int, error) {
+	var prefix string
+	var flags = stdHeaderFlags
+	if this.std != nil {
+		prefix, flags = this.std.Prefix(), this.std.Flags()
+	}
+	var skip int
+	var isSkipKnown bool
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		msg := stripStdHeader(string(bytes.TrimRight(line, "\r")), prefix, flags)
+		level := this.level
+		if this.options.ParseLevelToken == true {
+			level, msg = parseLevelToken(msg, this.level)
+		}
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		if isSkipKnown == false && (this.logger.callerLevels[level] == true || this.logger.stackLevels[level] == true) {
+			skip, isSkipKnown = stdWriterSkip(), true
+		}
+		this.logger.log(entryType{level: level, targets: this.logger.levelTargets(level), message: msg, skip: skip})
+	}
+	return len(p), nil
+}
+
+/*	Количество фреймов пакетов gStdWriterPackages между Write и пользовательским кодом  */
+func stdWriterSkip() int {
+	var pcs = make([]uintptr, 16)
+	/*	runtime.Callers -> stdWriterSkip -> Write  */
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	var skip int
+	for {
+		frame, isMore := frames.Next()
+		if isStdWriterFrame(frame.Function) == false || isMore == false {
+			return skip
+		}
+		skip++
+	}
+}
+
+func isStdWriterFrame(function string) bool {
+	for _, prefix := range gStdWriterPackages {
+		if strings.HasPrefix(function, prefix) == true {
+			return true
+		}
+	}
+	return false
+}
+
+/*	Отрезает заголовок стандартного логгера по его флагам: префикс (в начале или после заголовка при Lmsgprefix),
+**	дату, время и файл. Каждая часть отрезается только если строка с нее начинается  */
+func stripStdHeader(line string, prefix string, flags int) string {
+	if prefix != "" && flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	if flags&log.Ldate != 0 {
+		line = trimStdDate(line)
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		line = trimStdTime(line)
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		line = trimStdFile(line)
+	}
+	if prefix != "" && flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	return line
+}
+
+/*	2009/01/23 */
+func trimStdDate(line string) string {
+	if matchDigits(line, "dddd/dd/dd ") == true {
+		return line[len("2009/01/23 "):]
+	}
+	return line
+}
+
+/*	01:23:23 или 01:23:23.123123 */
+func trimStdTime(line string) string {
+	if matchDigits(line, "dd:dd:dd") == false {
+		return line
+	}
+	rest := line[len("01:23:23"):]
+	if strings.HasPrefix(rest, ".") == true {
+		rest = strings.TrimLeft(rest[1:], "0123456789")
+	}
+	if strings.HasPrefix(rest, " ") == false {
+		return line
+	}
+	return rest[1:]
+}
+
+/*	file.go:23: или /a/b/c/d.go:23: */
+func trimStdFile(line string) string {
+	index := strings.Index(line, ": ")
+	if index < 0 {
+		return line
+	}
+	file := line[:index]
+	colon := strings.LastIndexByte(file, ':')
+	if colon < 0 || strings.HasSuffix(file[:colon], ".go") == false || strings.ContainsRune(file[:colon], ' ') == true {
+		return line
+	}
+	if lineNum := file[colon+1:]; lineNum == "" || strings.Trim(lineNum, "0123456789") != "" {
+		return line
+	}
+	return line[index+2:]
+}
+
+/*	В шаблоне d - любая цифра, остальные символы должны совпадать  */
+func matchDigits(line string, pattern string) bool {
+	if len(line) < len(pattern) {
+		return false
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == 'd' {
+			if line[i] < '0' || line[i] > '9' {
+				return false
+			}
+		} else if line[i] != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*	[LEVEL] в начале строки - уровень записи. Неизвестный уровень в скобках и уровень с триггером выше
+**	defaultLevel остаются частью сообщения  */
+func parseLevelToken(line string, defaultLevel LevelType) (LevelType, string) {
+	if strings.HasPrefix(line, "[") == false {
+		return defaultLevel, line
+	}
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return defaultLevel, line
+	}
+	name := line[1:end]
+	if strings.EqualFold(name, "WARN") == true {
+		name = warningLevel
+	}
+	for level := LevelType(0); level < levelCount; level++ {
+		if strings.EqualFold(name, gLevelNames[level]) == true {
+			if isLevelRaised(level, defaultLevel) == true {
+				return defaultLevel, line
+			}
+			return level, strings.TrimLeft(line[end+1:], " ")
+		}
+	}
+	return defaultLevel, line
+}
+
+/*	Уровень level поднимает запись над defaultLevel если у него есть триггер мониторинга (FATAL, ERROR, IMPORTANT)
+**	и defaultLevel не такой же или более серьезный уровень с триггером  */
+func isLevelRaised(level LevelType, defaultLevel LevelType) bool {
+	switch level {
+	case levelFatal:
+		return defaultLevel != levelFatal
+	case levelError:
+		return defaultLevel != levelFatal && defaultLevel != levelError
+	case levelImportant:
+		return defaultLevel != levelFatal && defaultLevel != levelError && defaultLevel != levelImportant
+	default:
+		return false
+	}
+}
@@ -0,0 +1,172 @@
+package flogger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	loggerConf := newTestConfig(t)
+	loggerConf.CallerLevels = []string{"WARNING"}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+
+	stdLogger := logger.StdLogger(LevelWarning, map[string]interface{}{"source": "http"}, nil)
+	stdLogger.SetPrefix("server: ")
+	stdLogger.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+	stdLogger.Printf("TLS handshake error")
+	stdLogger.Print("first line\n\nsecond line")
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered == nil {
+				t.Errorf("%sFail: expected panic%s", RED_BG, NO_COLOR)
+			}
+		}()
+		stdLogger.Panicf("handler panic")
+	}()
+
+	writer := logger.Writer(LevelWarning, nil, &StdOptionsType{ParseLevelToken: true})
+	fmt.Fprintf(writer, "[INFO] connection lost\n[debug] disabled level\n[unknown] stays in message\n[FATAL] user supplied text\n")
+	fmt.Fprintf(logger.Writer(LevelWarning, nil, nil), "written by fmt")
+	fmt.Fprintf(logger.Writer(LevelInfo, nil, nil), "[WARN] not parsed by default")
+
+	lines := strings.Split(strings.TrimSpace(stopAndReadLogFile(t, logger, wg, "default")), "\n")
+	if len(lines) != 9 {
+		t.Errorf("%sFail: expected 9 records got %d%s", RED_BG, len(lines), NO_COLOR)
+		t.FailNow()
+	}
+	var testCases = []struct {
+		name     string
+		expected []string
+	}{
+		{
+			name:     "prefix and flags",
+			expected: []string{`"level":"WARNING","caller":"`, `/stdlog_test.go:26"`, `"source":"http","message":"TLS handshake error"}`},
+		},
+		{
+			name:     "first line",
+			expected: []string{`"level":"WARNING"`, `"source":"http","message":"first line"}`},
+		},
+		{
+			name:     "second line",
+			expected: []string{`"level":"WARNING"`, `"source":"http","message":"second line"}`},
+		},
+		{
+			name:     "panicf caller",
+			expected: []string{`"level":"WARNING","caller":"`, `/stdlog_test.go:35"`, `"message":"handler panic"}`},
+		},
+		{
+			name:     "level token",
+			expected: []string{`"level":"INFO","message":"connection lost"}`},
+		},
+		{
+			name:     "unknown level token",
+			expected: []string{`"level":"WARNING","caller":"`, `/stdlog_test.go:39"`, `"message":"[unknown] stays in message"}`},
+		},
+		{
+			name:     "level token above writer level",
+			expected: []string{`"level":"WARNING","caller":"`, `"message":"[FATAL] user supplied text"}`},
+		},
+		{
+			name:     "fmt caller",
+			expected: []string{`"level":"WARNING","caller":"`, `/stdlog_test.go:40"`, `"message":"written by fmt"}`},
+		},
+		{
+			name:     "level token not parsed",
+			expected: []string{`"level":"INFO","message":"[WARN] not parsed by default"}`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, expected := range tc.expected {
+				if strings.Contains(lines[i], expected) == false {
+					t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, lines[i], NO_COLOR)
+				}
+			}
+		})
+	}
+}
+
+func TestStripStdHeader(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		line     string
+		prefix   string
+		flags    int
+		expected string
+	}{
+		{name: "no flags", line: "2009/01/23 01:23:23 message", flags: 0, expected: "2009/01/23 01:23:23 message"},
+		{name: "std flags", line: "2009/01/23 01:23:23 message", flags: log.LstdFlags, expected: "message"},
+		{name: "microseconds", line: "01:23:23.123123 message", flags: log.Lmicroseconds, expected: "message"},
+		{name: "long file", line: "/a/b/c/d.go:23: message", flags: log.Llongfile, expected: "message"},
+		{name: "not a file", line: "key: value", flags: log.Lshortfile, expected: "key: value"},
+		{name: "prefix", line: "srv: 01:23:23 message", prefix: "srv: ", flags: log.Ltime, expected: "message"},
+		{name: "message prefix", line: "01:23:23 srv: message", prefix: "srv: ", flags: log.Ltime | log.Lmsgprefix, expected: "message"},
+		{name: "unknown flags", line: "2009/01/23 01:23:23 d.go:23: message", flags: stdHeaderFlags, expected: "message"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := stripStdHeader(tc.line, tc.prefix, tc.flags); result != tc.expected {
+				t.Errorf("%sFail: expected %q got %q%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+		})
+	}
+}
+
+func TestParseLevelToken(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		line          string
+		defaultLevel  LevelType
+		expectedLevel LevelType
+		expected      string
+	}{
+		{name: "lower level", line: "[debug] message", defaultLevel: LevelWarning, expectedLevel: LevelServiceDebug, expected: "message"},
+		{name: "warn alias", line: "[WARN] message", defaultLevel: LevelInfo, expectedLevel: LevelWarning, expected: "message"},
+		{name: "error below error", line: "[ERROR] message", defaultLevel: LevelError, expectedLevel: LevelError, expected: "message"},
+		{name: "error above warning", line: "[ERROR] message", defaultLevel: LevelWarning, expectedLevel: LevelWarning, expected: "[ERROR] message"},
+		{name: "fatal above error", line: "[FATAL] message", defaultLevel: LevelError, expectedLevel: LevelError, expected: "[FATAL] message"},
+		{name: "important above info", line: "[IMPORTANT] message", defaultLevel: LevelInfo, expectedLevel: LevelInfo, expected: "[IMPORTANT] message"},
+		{name: "no token", line: "message", defaultLevel: LevelInfo, expectedLevel: LevelInfo, expected: "message"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			level, result := parseLevelToken(tc.line, tc.defaultLevel)
+			if level != tc.expectedLevel || result != tc.expected {
+				t.Errorf("%sFail: expected %s %q got %s %q%s", RED_BG, tc.expectedLevel, tc.expected, level, result, NO_COLOR)
+			}
+		})
+	}
+}
+
+func TestUnknownLevel(t *testing.T) {
+	if result := LevelType(42).String(); result != "LEVEL(42)" {
+		t.Errorf("%sFail: expected LEVEL(42) got %s%s", RED_BG, result, NO_COLOR)
+	}
+
+	loggerConf := newTestConfig(t)
+	loggerConf.CallerLevels = []string{"INFO"}
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	logger, err := NewLogger(wg)
+	if err != nil {
+		t.Errorf("%sError: %s%s", RED_BG, err, NO_COLOR)
+		t.FailNow()
+	}
+	fmt.Fprintf(logger.Writer(LevelType(42), nil, nil), "unknown level")
+
+	body := stopAndReadLogFile(t, logger, wg, "default")
+	if expected := `"level":"INFO"`; strings.Contains(body, expected) == false {
+		t.Errorf("%sFail: expected %s in %s%s", RED_BG, expected, body, NO_COLOR)
+	}
+}
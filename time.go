@@ -8,12 +8,8 @@ type timeType struct {
 	Time time.Time
 }
 
-/*	Сериализуем время (часы минуты секунды) в строку  */
+/*	Сериализуем время в строку в формате по умолчанию (часы минуты секунды). TimeLayout задается пользователем
+**	и может содержать кавычки и управляющие символы - поэтому экранируем  */
 func (this timeType) MarshalJSON() ([]byte, error) {
-	return []byte(this.marshalString()), nil
-}
-
-func (this timeType) marshalString() string {
-	layout := "15:04:05"
-	return "\"" + this.Time.Format(layout) + "\""
+	return appendJSONString(nil, gDefaultFormat.timeText(this.Time)), nil
 }
@@ -0,0 +1,461 @@
+package flogger
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*	Защита от очень глубоких (и от циклических через интерфейсы) значений  */
+const maxValueDepth = 64
+
+/*	Путь обхода значения - указатели, мапы и слайсы через которые мы сейчас проходим.
+**	Если указатель встречается на пути повторно - значение циклическое  */
+type valuePathType struct {
+	visited []visitedType
+	depth   int
+	format  *formatType // формат чисел с плавающей точкой и порядка полей
+}
+
+/*	Указатель на структуру и на ее первое поле совпадают - поэтому циклом считается только совпадение и адреса и типа  */
+type visitedType struct {
+	pointer   uintptr
+	valueType reflect.Type
+}
+
+/*	Дописывает значение поля в формате json. Частые типы обрабатываются без рефлексии,
+**	все остальное - через рефлексию (описание структур кешируется)  */
+func appendValue(dst []byte, src interface{}, format *formatType) []byte {
+	return appendValueWithPath(dst, src, &valuePathType{format: format})
+}
+
+/*	Значение поля записи. Типизированные поля пишутся без упаковки в interface{}  */
+func appendFieldValue(dst []byte, field *FieldType, path *valuePathType) []byte {
+	switch field.kind {
+	case fieldKindString:
+		return appendJSONString(dst, field.text)
+	case fieldKindInt64:
+		return strconv.AppendInt(dst, field.integer, 10)
+	case fieldKindBool:
+		return strconv.AppendBool(dst, field.integer != 0)
+	case fieldKindDuration:
+		return appendJSONString(dst, time.Duration(field.integer).String())
+	case fieldKindTime:
+		/*	RFC 3339 не требует экранирования  */
+		dst = append(dst, '"')
+		dst = field.timeValue().AppendFormat(dst, time.RFC3339Nano)
+		return append(dst, '"')
+	default:
+		return appendValueWithPath(dst, field.Value, path)
+	}
+}
+
+func appendValueWithPath(dst []byte, src interface{}, path *valuePathType) []byte {
+	switch typed := src.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendJSONString(dst, typed)
+	case int:
+		return strconv.AppendInt(dst, int64(typed), 10)
+	case int64:
+		return strconv.AppendInt(dst, typed, 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(typed), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(typed), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(typed), 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(typed), 10)
+	case uint64:
+		return strconv.AppendUint(dst, typed, 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(typed), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(typed), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(typed), 10)
+	case uintptr:
+		return strconv.AppendUint(dst, uint64(typed), 10)
+	case float64:
+		return path.format.appendFloat(dst, typed, 64)
+	case float32:
+		return path.format.appendFloat(dst, float64(typed), 32)
+	case bool:
+		return strconv.AppendBool(dst, typed)
+	case time.Time:
+		return appendJSONString(dst, typed.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendJSONString(dst, typed.String())
+	case []byte:
+		if typed == nil {
+			return append(dst, "null"...)
+		}
+		return appendBase64(dst, typed)
+	case []string:
+		if typed == nil {
+			return append(dst, "null"...)
+		}
+		dst = append(dst, '[')
+		for i, value := range typed {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, value)
+		}
+		return append(dst, ']')
+	case map[string]interface{}:
+		if typed == nil {
+			return append(dst, "null"...)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendJSONString(dst, marker)
+		}
+		keyList := getSortedKeys(typed)
+		dst = append(dst, '{')
+		for i, key := range *keyList {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, key)
+			dst = append(dst, ':')
+			dst = appendValueWithPath(dst, typed[key], path)
+		}
+		putKeyList(keyList)
+		path.leave()
+		return append(dst, '}')
+	case []FieldType:
+		/*	Упорядоченный список полей - объект в котором можно сохранить порядок ключей (параметр KeepFieldsOrder)  */
+		if typed == nil {
+			return append(dst, "null"...)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendJSONString(dst, marker)
+		}
+		dst = append(dst, '{')
+		for i, field := range path.format.orderFields(typed) {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, field.Key)
+			dst = append(dst, ':')
+			dst = appendFieldValue(dst, &field, path)
+		}
+		path.leave()
+		return append(dst, '}')
+	case []interface{}:
+		if typed == nil {
+			return append(dst, "null"...)
+		}
+		if marker := path.enter(reflect.ValueOf(typed)); marker != "" {
+			return appendJSONString(dst, marker)
+		}
+		dst = append(dst, '[')
+		for i, value := range typed {
+			dst = appendValueWithPath(dst, value, path)
+			if i < len(typed)-1 {
+				dst = append(dst, ',')
+			}
+		}
+		path.leave()
+		return append(dst, ']')
+	}
+	return appendReflectValue(dst, reflect.ValueOf(src), path)
+}
+
+/*	Пустой указатель (в том числе завернутый в интерфейс error или Stringer) кодируется как null.
+**	Методы пользовательских типов (MarshalJSON, Error, String) вызываются с защитой от паники -
+**	запись лога не должна ронять горутину записи в файл  */
+func appendReflectValue(dst []byte, value reflect.Value, path *valuePathType) []byte {
+	if value.IsValid() == false {
+		return append(dst, "null"...)
+	}
+	if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() == true {
+		return append(dst, "null"...)
+	}
+
+	if value.CanInterface() == true {
+		switch typed := value.Interface().(type) {
+		case json.Marshaler:
+			return appendJSONMarshaler(dst, typed)
+		case error:
+			return appendJSONString(dst, safeString(typed.Error))
+		case fmt.Stringer:
+			return appendJSONString(dst, safeString(typed.String))
+		case encoding.TextMarshaler:
+			return appendTextMarshaler(dst, typed)
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return strconv.AppendBool(dst, value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(dst, value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(dst, value.Uint(), 10)
+	case reflect.Float32:
+		return path.format.appendFloat(dst, value.Float(), 32)
+	case reflect.Float64:
+		return path.format.appendFloat(dst, value.Float(), 64)
+	case reflect.String:
+		return appendJSONString(dst, value.String())
+	case reflect.Ptr:
+		if marker := path.enter(value); marker != "" {
+			return appendJSONString(dst, marker)
+		}
+		dst = appendReflectValue(dst, value.Elem(), path)
+		path.leave()
+		return dst
+	case reflect.Interface:
+		return appendReflectValue(dst, value.Elem(), path)
+	case reflect.Struct:
+		return appendStruct(dst, value, path)
+	case reflect.Map:
+		return appendReflectMap(dst, value, path)
+	case reflect.Slice:
+		if value.IsNil() == true {
+			return append(dst, "null"...)
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return appendBase64(dst, value.Bytes())
+		}
+		if marker := path.enter(value); marker != "" {
+			return appendJSONString(dst, marker)
+		}
+		dst = appendReflectArray(dst, value, path)
+		path.leave()
+		return dst
+	case reflect.Array:
+		if path.depth >= maxValueDepth {
+			return appendJSONString(dst, "<max depth>")
+		}
+		path.depth++
+		dst = appendReflectArray(dst, value, path)
+		path.depth--
+		return dst
+	default:
+		/*	Каналы, функции, комплексные числа - в json им нет соответствия, пишется название типа  */
+		return appendJSONString(dst, value.Type().String())
+	}
+}
+
+func appendReflectArray(dst []byte, value reflect.Value, path *valuePathType) []byte {
+	dst = append(dst, '[')
+	for i := 0; i < value.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendReflectValue(dst, value.Index(i), path)
+	}
+	return append(dst, ']')
+}
+
+/*	Ключи мап сортируются - одинаковые значения всегда сериализуются одинаково  */
+func appendReflectMap(dst []byte, value reflect.Value, path *valuePathType) []byte {
+	if value.IsNil() == true {
+		return append(dst, "null"...)
+	}
+	if marker := path.enter(value); marker != "" {
+		return appendJSONString(dst, marker)
+	}
+	type mapEntryType struct {
+		key   string
+		value reflect.Value
+	}
+	var entries = make([]mapEntryType, 0, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		entries = append(entries, mapEntryType{key: mapKeyString(iter.Key()), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	dst = append(dst, '{')
+	for i, entry := range entries {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, entry.key)
+		dst = append(dst, ':')
+		dst = appendReflectValue(dst, entry.value, path)
+	}
+	path.leave()
+	return append(dst, '}')
+}
+
+func mapKeyString(key reflect.Value) string {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10)
+	}
+	if textMarshaler, isTextMarshaler := key.Interface().(encoding.TextMarshaler); isTextMarshaler == true {
+		if text, err := textMarshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+/*	Описание поля структуры для сериализации. Ключ хранится уже экранированным вместе с двоеточием  */
+type structFieldType struct {
+	key       []byte
+	index     []int
+	omitEmpty bool
+}
+
+/*	Кеш описаний структур: reflect.Type -> []structFieldType  */
+var gStructFields = &sync.Map{}
+
+func appendStruct(dst []byte, value reflect.Value, path *valuePathType) []byte {
+	if path.depth >= maxValueDepth {
+		return appendJSONString(dst, "<max depth>")
+	}
+	path.depth++
+	dst = append(dst, '{')
+	var isFirst = true
+	for _, field := range getStructFields(value.Type()) {
+		fieldValue, err := value.FieldByIndexErr(field.index)
+		if err != nil {
+			continue // поле встроенной по nil указателю структуры
+		}
+		if field.omitEmpty == true && fieldValue.IsZero() == true {
+			continue
+		}
+		if isFirst == false {
+			dst = append(dst, ',')
+		}
+		isFirst = false
+		dst = append(dst, field.key...)
+		dst = appendReflectValue(dst, fieldValue, path)
+	}
+	path.depth--
+	return append(dst, '}')
+}
+
+/*	Поля структуры в порядке объявления с учетом json тэгов (имя, omitempty, "-").
+**	Поля встроенных структур поднимаются на уровень выше, как в encoding/json  */
+func getStructFields(structType reflect.Type) []structFieldType {
+	if cached, isExists := gStructFields.Load(structType); isExists == true {
+		return cached.([]structFieldType)
+	}
+	var fields []structFieldType
+	for _, field := range reflect.VisibleFields(structType) {
+		if field.Anonymous == true && field.Tag.Get("json") == "" && isStructType(field.Type) == true {
+			continue // поля встроенной структуры уже есть в списке как поднятые
+		}
+		if field.IsExported() == false {
+			continue
+		}
+		name := field.Name
+		var omitEmpty bool
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			tagName, options, _ := strings.Cut(tag, ",")
+			if tagName != "" {
+				name = tagName
+			}
+			omitEmpty = strings.Contains(","+options+",", ",omitempty,")
+		}
+		fields = append(fields, structFieldType{
+			key:       append(appendJSONString(nil, name), ':'),
+			index:     field.Index,
+			omitEmpty: omitEmpty,
+		})
+	}
+	gStructFields.Store(structType, fields)
+	return fields
+}
+
+func isStructType(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Struct
+}
+
+/*	Результат MarshalJSON уплотняется (json.Compact) - так он гарантированно валиден и не содержит переводов строк  */
+func appendJSONMarshaler(dst []byte, marshaler json.Marshaler) (result []byte) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = appendJSONString(dst, fmt.Sprintf("<panic: %v>", recovered))
+		}
+	}()
+	jsonB, err := marshaler.MarshalJSON()
+	if err != nil {
+		return appendJSONString(dst, "<error: "+err.Error()+">")
+	}
+	var buf = bytes.NewBuffer(dst)
+	if err := json.Compact(buf, jsonB); err != nil {
+		return appendJSONString(dst, "<error: "+err.Error()+">")
+	}
+	return buf.Bytes()
+}
+
+func appendTextMarshaler(dst []byte, marshaler encoding.TextMarshaler) (result []byte) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = appendJSONString(dst, fmt.Sprintf("<panic: %v>", recovered))
+		}
+	}()
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return appendJSONString(dst, "<error: "+err.Error()+">")
+	}
+	return appendJSONString(dst, string(text))
+}
+
+/*	[]byte кодируется в base64 как в encoding/json  */
+func appendBase64(dst []byte, src []byte) []byte {
+	start := len(dst) + 1
+	dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(src))+2)...)
+	dst[start-1] = '"'
+	base64.StdEncoding.Encode(dst[start:], src)
+	dst[len(dst)-1] = '"'
+	return dst
+}
+
+func safeString(method func() string) (result string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = fmt.Sprintf("<panic: %v>", recovered)
+		}
+	}()
+	return method()
+}
+
+/*	Возвращает маркер который нужно записать вместо значения если указатель уже есть на пути (цикл)
+**	либо превышена глубина. Пустая строка - можно заходить внутрь значения  */
+func (this *valuePathType) enter(value reflect.Value) string {
+	if this.depth >= maxValueDepth {
+		return "<max depth>"
+	}
+	current := visitedType{pointer: value.Pointer(), valueType: value.Type()}
+	for _, visited := range this.visited {
+		if visited == current {
+			return "<cycle>"
+		}
+	}
+	this.visited = append(this.visited, current)
+	this.depth++
+	return ""
+}
+
+func (this *valuePathType) leave() {
+	this.visited = this.visited[:len(this.visited)-1]
+	this.depth--
+}
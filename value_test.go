@@ -0,0 +1,170 @@
+package flogger
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+type valueStructType struct {
+	Name     string `json:"name"`
+	Count    int    `json:"count,omitempty"`
+	Skipped  string `json:"-"`
+	private  string
+	Tags     []string          `json:"tags"`
+	Labels   map[string]int    `json:"labels"`
+	Started  time.Time         `json:"started"`
+	Timeout  time.Duration     `json:"timeout"`
+	Next     *valueStructType  `json:"next,omitempty"`
+	Embedded                   // поля поднимаются на уровень выше
+	Payload  json.RawMessage   `json:"payload,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+type Embedded struct {
+	Region string
+}
+
+type panicStringerType struct{}
+
+func (this panicStringerType) String() string {
+	panic("boom")
+}
+
+type nilErrorType struct{}
+
+func (this *nilErrorType) Error() string {
+	return "never called"
+}
+
+func TestAppendValue(t *testing.T) {
+	started := time.Date(2022, 12, 18, 10, 0, 0, 5, time.UTC)
+	var nilError *nilErrorType
+	var nilMap map[string]interface{}
+
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: "nil", value: nil, expected: `null`},
+		{name: "int8", value: int8(-8), expected: `-8`},
+		{name: "int16", value: int16(-16), expected: `-16`},
+		{name: "uint8", value: uint8(8), expected: `8`},
+		{name: "uint16", value: uint16(16), expected: `16`},
+		{name: "time", value: started, expected: `"2022-12-18T10:00:00.000000005Z"`},
+		{name: "duration", value: 1500 * time.Millisecond, expected: `"1.5s"`},
+		{name: "error", value: errors.New("cant \"do\""), expected: `"cant \"do\""`},
+		{name: "nil error pointer", value: nilError, expected: `null`},
+		{name: "stringer", value: net.IPv4(127, 0, 0, 1), expected: `"127.0.0.1"`},
+		{name: "panic stringer", value: panicStringerType{}, expected: `"<panic: boom>"`},
+		{name: "json marshaler", value: json.RawMessage("{ \"a\" :\n 1 }"), expected: `{"a":1}`},
+		{name: "bytes", value: []byte("hello"), expected: `"aGVsbG8="`},
+		{name: "strings", value: []string{"a", "b\n"}, expected: `["a","b\n"]`},
+		{name: "ints", value: []int{1, 2, 3}, expected: `[1,2,3]`},
+		{name: "array", value: [2]bool{true, false}, expected: `[true,false]`},
+		{name: "nil map", value: nilMap, expected: `null`},
+		{name: "typed map", value: map[int]string{2: "b", 1: "a"}, expected: `{"1":"a","2":"b"}`},
+		{name: "pointer", value: &started, expected: `"2022-12-18T10:00:00.000000005Z"`},
+		{name: "channel", value: make(chan int), expected: `"chan int"`},
+		{
+			name: "struct",
+			value: valueStructType{
+				Name:     "n",
+				Skipped:  "skipped",
+				private:  "private",
+				Tags:     []string{"x"},
+				Labels:   map[string]int{"b": 2, "a": 1},
+				Started:  started,
+				Timeout:  time.Second,
+				Embedded: Embedded{Region: "eu"},
+			},
+			expected: `{"name":"n","tags":["x"],"labels":{"a":1,"b":2},"started":"2022-12-18T10:00:00.000000005Z","timeout":"1s","Region":"eu"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := appendValue(nil, tc.value, gDefaultFormat)
+			if string(result) != tc.expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, tc.expected, result, NO_COLOR)
+			}
+			if json.Valid(result) == false {
+				t.Errorf("%sFail: invalid json %s%s", RED_BG, result, NO_COLOR)
+			}
+		})
+	}
+
+	t.Run("cyclic map", func(t *testing.T) {
+		cyclic := map[string]interface{}{"a": 1}
+		cyclic["self"] = cyclic
+		if result := appendValue(nil, cyclic, gDefaultFormat); string(result) != `{"a":1,"self":"<cycle>"}` {
+			t.Errorf("%sFail: got %s%s", RED_BG, result, NO_COLOR)
+		}
+	})
+
+	t.Run("cyclic struct", func(t *testing.T) {
+		cyclic := &valueStructType{Name: "loop"}
+		cyclic.Next = cyclic
+		result := appendValue(nil, cyclic, gDefaultFormat)
+		expected := `{"name":"loop","tags":null,"labels":null,"started":"0001-01-01T00:00:00Z","timeout":"0s","next":"<cycle>","Region":""}`
+		if string(result) != expected {
+			t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, result, NO_COLOR)
+		}
+	})
+
+	t.Run("cyclic slice", func(t *testing.T) {
+		cyclic := make([]interface{}, 1)
+		cyclic[0] = cyclic
+		if result := appendValue(nil, cyclic, gDefaultFormat); string(result) != `["<cycle>"]` {
+			t.Errorf("%sFail: got %s%s", RED_BG, result, NO_COLOR)
+		}
+	})
+
+	t.Run("nested maps are sorted", func(t *testing.T) {
+		value := map[string]interface{}{
+			"b": map[string]interface{}{"z": 1, "y": map[string]interface{}{"2": 2, "1": 1}},
+			"a": []interface{}{map[string]interface{}{"d": 4, "c": 3}},
+		}
+		expected := `{"a":[{"c":3,"d":4}],"b":{"y":{"1":1,"2":2},"z":1}}`
+		for i := 0; i < 20; i++ {
+			if result := appendValue(nil, value, gDefaultFormat); string(result) != expected {
+				t.Errorf("%sFail: expected %s got %s%s", RED_BG, expected, result, NO_COLOR)
+				t.FailNow()
+			}
+		}
+	})
+
+	t.Run("ordered fields", func(t *testing.T) {
+		value := []FieldType{{Key: "b", Value: 2}, {Key: "a", Value: 1}}
+
+		if result := appendValue(nil, value, &formatType{}); string(result) != `{"a":1,"b":2}` {
+			t.Errorf("%sFail: got %s%s", RED_BG, result, NO_COLOR)
+		}
+		if value[0].Key != "b" {
+			t.Errorf("%sFail: caller slice was modified%s", RED_BG, NO_COLOR)
+		}
+
+		if result := appendValue(nil, value, &formatType{keepFieldsOrder: true}); string(result) != `{"b":2,"a":1}` {
+			t.Errorf("%sFail: got %s%s", RED_BG, result, NO_COLOR)
+		}
+	})
+}
+
+/*	go test -bench . -benchmem  */
+func BenchmarkAppendNestedMap(b *testing.B) {
+	value := map[string]interface{}{
+		"worker": 1,
+		"request": map[string]interface{}{
+			"method": "GET",
+			"path":   "/api/v1/users",
+			"query":  map[string]interface{}{"limit": 10, "offset": 20},
+		},
+	}
+	var dst = make([]byte, 0, 256)
+	for i := 0; i < b.N; i++ {
+		dst = appendValue(dst[:0], value, gDefaultFormat)
+	}
+}